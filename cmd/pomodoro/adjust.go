@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func adjustWorkDuration(c *client.Client, command string) {
+	response, err := c.Command(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(messages.Text("status.work-duration")+"\n",
+		protocol.FormatShortDuration(response.Config.WorkDuration),
+		messages.Period(response.Status.Period),
+		response.Status.RestOfTimeStr,
+	)
+}
+
+func setDuration(c *client.Client, args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: set work|rest|long-rest <duration>\n")
+		os.Exit(1)
+	}
+
+	request := protocol.Request{
+		Cmd:  "set",
+		Args: map[string]string{"period": args[0], "duration": args[1]},
+	}
+
+	response, err := c.Do(request)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printConfigValues(response.Config)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	autostartTimeout = 5 * time.Second
+	autostartPoll    = 50 * time.Millisecond
+)
+
+// ensureDaemon starts the daemon in the background when nothing listens on
+// the socket, passing it the daemon flags the client was run with, and
+// waits until it accepts connections.
+func ensureDaemon(address string, flags []string) error {
+	if daemonReachable(address) || !isLocalAddress(address) {
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to start the daemon: %w", err)
+	}
+
+	args := append(slices.Clone(flags), "--socket-path", address, "daemon")
+
+	cmd := exec.Command(executable, args...)
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the daemon: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	deadline := time.After(autostartTimeout)
+
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exited")
+			}
+
+			// The daemon's output is discarded, it logs for as long as it
+			// runs and the client does not stay around to read it.
+			return fmt.Errorf("failed to start the daemon: %w, run the daemon command with the same options to see why", err)
+		case <-deadline:
+			return fmt.Errorf("daemon did not start listening on %s within %s", address, autostartTimeout)
+		case <-time.After(autostartPoll):
+			if daemonReachable(address) {
+				return nil
+			}
+		}
+	}
+}
+
+func daemonReachable(address string) bool {
+	conn, err := protocol.AddressTransport{}.Dial(address)
+	if err != nil {
+		return false
+	}
+
+	_ = conn.Close()
+
+	return true
+}
+
+// isLocalAddress tells whether a daemon started here would listen on the
+// address, which is not the case for a tcp address of another machine.
+func isLocalAddress(address string) bool {
+	hostPort, ok := strings.CutPrefix(address, "tcp://")
+	if !ok {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+
+	return host == "localhost" || ip != nil && ip.IsLoopback()
+}
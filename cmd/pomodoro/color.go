@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+	"golang.org/x/term"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+const ansiReset = "\x1b[0m"
+
+var periodColors = map[protocol.Period]string{
+	protocol.Work:     "\x1b[31m",
+	protocol.Rest:     "\x1b[32m",
+	protocol.LongRest: "\x1b[32m",
+	protocol.Prepare:  "\x1b[33m",
+}
+
+func useColor(mode string) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	default:
+		return term.IsTerminal(int(os.Stdout.Fd()))
+	}
+}
+
+func colorize(text string, period protocol.Period) string {
+	code, ok := periodColors[period]
+	if !ok {
+		return text
+	}
+
+	return code + text + ansiReset
+}
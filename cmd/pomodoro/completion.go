@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+)
+
+type commandInfo struct {
+	name        string
+	description string
+}
+
+var commands = []commandInfo{
+	{"daemon", "Run the timer daemon, or stop or reload the running one"},
+	{"get", "Print the current status"},
+	{"toggle", "Start or stop the timer"},
+	{"start", "Start a work period, optionally tagged or attached to a Taskwarrior task"},
+	{"continue", "Start the next period when waiting"},
+	{"ack", "Acknowledge the end of a period and start the next one"},
+	{"pause", "Pause the running timer"},
+	{"resume", "Resume the paused timer"},
+	{"skip", "Jump to the next period"},
+	{"snooze", "Postpone the break"},
+	{"timer", "Run a single timer for a duration"},
+	{"mute", "Silence notifications for a duration"},
+	{"unmute", "Enable notifications again"},
+	{"idle", "Tell the daemon the user went idle, for swayidle"},
+	{"active", "Tell the daemon the user is back, for swayidle"},
+	{"set", "Change the duration of a period"},
+	{"work-inc", "Increase the work duration"},
+	{"work-dec", "Decrease the work duration"},
+	{"restart", "Restart the cycle with a work period"},
+	{"reset", "Start the current period over"},
+	{"config", "Print the daemon configuration"},
+	{"uptime", "Print how long the daemon has been running"},
+	{"watch", "Stream status updates"},
+	{"tui", "Show a full screen countdown with keys for toggle, pause and skip"},
+	{"tray", "Show the remaining time in the system tray"},
+	{"subscribe", "Stream status updates as JSON lines"},
+	{"history", "Print finished periods"},
+	{"stats", "Print completed work periods of today, this week and this month"},
+	{"stop-daemon", "Stop the daemon"},
+	{"completion", "Print a shell completion script"},
+}
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func printCompletion(parser *flags.Parser, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: completion %s\n", strings.Join(completionShells, "|"))
+		os.Exit(1)
+	}
+
+	options := groupOptions(parser.Command.Group)
+
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout, options)
+	case "zsh":
+		writeZshCompletion(os.Stdout, options)
+	case "fish":
+		writeFishCompletion(os.Stdout, options)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown shell: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func groupOptions(group *flags.Group) []*flags.Option {
+	options := group.Options()
+
+	for _, child := range group.Groups() {
+		options = append(options, groupOptions(child)...)
+	}
+
+	return options
+}
+
+func optionTakesValue(option *flags.Option) bool {
+	return option.Field().Type.Kind() != reflect.Bool
+}
+
+// optionTakesPath reports whether file names are worth completing as the
+// option value, which is only the case for plain string options.
+func optionTakesPath(option *flags.Option) bool {
+	return option.Field().Type.Kind() == reflect.String && len(option.Choices) == 0
+}
+
+func commandNames() string {
+	names := make([]string, 0, len(commands))
+	for _, command := range commands {
+		names = append(names, command.name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer, options []*flags.Option) {
+	var names []string
+
+	for _, option := range options {
+		names = append(names, "--"+option.LongName)
+		if option.ShortName != 0 {
+			names = append(names, "-"+string(option.ShortName))
+		}
+	}
+
+	fmt.Fprintln(w, "_pomodoro() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `	case "$prev" in`)
+
+	for _, option := range options {
+		if !optionTakesValue(option) {
+			continue
+		}
+
+		pattern := "--" + option.LongName
+		if option.ShortName != 0 {
+			pattern += "|-" + string(option.ShortName)
+		}
+
+		var reply string
+
+		switch {
+		case len(option.Choices) > 0:
+			reply = fmt.Sprintf("($(compgen -W %q -- \"$cur\"))", strings.Join(option.Choices, " "))
+		case optionTakesPath(option):
+			reply = `($(compgen -f -- "$cur"))`
+		default:
+			reply = "()"
+		}
+
+		fmt.Fprintf(w, "\t%s)\n\t\tCOMPREPLY=%s\n\t\treturn\n\t\t;;\n", pattern, reply)
+	}
+
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `	if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `	if [[ "$prev" == completion ]]; then`)
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(completionShells, " "))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", commandNames())
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "complete -F _pomodoro pomodoro")
+}
+
+var zshEscaper = strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+
+func writeZshCompletion(w io.Writer, options []*flags.Option) {
+	fmt.Fprintln(w, "#compdef pomodoro")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_pomodoro() {")
+	fmt.Fprintln(w, "\tlocal -a commands")
+	fmt.Fprintln(w, "\tcommands=(")
+
+	for _, command := range commands {
+		fmt.Fprintf(w, "\t\t'%s:%s'\n", command.name, zshEscaper.Replace(command.description))
+	}
+
+	fmt.Fprintln(w, "\t)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\t_arguments \\")
+
+	for _, option := range options {
+		spec := "[" + zshEscaper.Replace(option.Description) + "]"
+
+		if optionTakesValue(option) {
+			spec = "=" + spec + ":value:"
+			if len(option.Choices) > 0 {
+				spec += "(" + strings.Join(option.Choices, " ") + ")"
+			} else if optionTakesPath(option) {
+				spec += "_files"
+			} else {
+				spec += " "
+			}
+		}
+
+		fmt.Fprintf(w, "\t\t'--%s%s' \\\n", option.LongName, spec)
+		if option.ShortName != 0 {
+			fmt.Fprintf(w, "\t\t'-%c%s' \\\n", option.ShortName, strings.TrimPrefix(spec, "="))
+		}
+	}
+
+	fmt.Fprintln(w, "\t\t'1:command:_describe command commands' \\")
+	fmt.Fprintf(w, "\t\t'2:shell:(%s)'\n", strings.Join(completionShells, " "))
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `_pomodoro "$@"`)
+}
+
+var fishEscaper = strings.NewReplacer(`\`, `\\`, "'", `\'`)
+
+func writeFishCompletion(w io.Writer, options []*flags.Option) {
+	fmt.Fprintln(w, "complete -c pomodoro -f")
+
+	for _, command := range commands {
+		fmt.Fprintf(w, "complete -c pomodoro -n __fish_use_subcommand -a %s -d '%s'\n",
+			command.name, fishEscaper.Replace(command.description))
+	}
+
+	fmt.Fprintf(w, "complete -c pomodoro -n '__fish_seen_subcommand_from completion' -a '%s'\n",
+		strings.Join(completionShells, " "))
+
+	for _, option := range options {
+		line := "complete -c pomodoro -l " + option.LongName
+		if option.ShortName != 0 {
+			line += " -s " + string(option.ShortName)
+		}
+
+		if optionTakesValue(option) {
+			if len(option.Choices) > 0 {
+				line += " -x -a '" + strings.Join(option.Choices, " ") + "'"
+			} else if optionTakesPath(option) {
+				line += " -r -F"
+			} else {
+				line += " -x"
+			}
+		}
+
+		fmt.Fprintf(w, "%s -d '%s'\n", line, fishEscaper.Replace(option.Description))
+	}
+}
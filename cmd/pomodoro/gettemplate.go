@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// statusTemplateData is available to the get template: every Status field
+// and method plus the remaining time of the default output.
+type statusTemplateData struct {
+	*protocol.Status
+	Remaining string
+}
+
+func formatStatusTemplate(text string, status *protocol.Status) (string, error) {
+	tmpl, err := template.New("get").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	err = tmpl.Execute(&sb, statusTemplateData{
+		Status:    status,
+		Remaining: status.RestOfTimeStr,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
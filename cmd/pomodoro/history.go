@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func printHistory(c *client.Client) {
+	response, err := c.Command("history")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tPERIOD\tLENGTH\tOVERTIME\tTAG")
+
+	for _, entry := range response.History {
+		overtime := ""
+		if entry.Overtime > 0 {
+			overtime = "+" + protocol.FormatDuration(entry.Overtime)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.StartedAt.Local().Format(time.DateTime),
+			messages.Period(entry.Period),
+			protocol.FormatDuration(entry.Duration),
+			overtime,
+			entry.Tag,
+		)
+	}
+
+	_ = w.Flush()
+
+	if len(response.Days) == 0 {
+		return
+	}
+
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tCOMPLETED")
+
+	for _, day := range response.Days {
+		fmt.Fprintf(w, "%s\t%d\n", day.Date, day.Completed)
+	}
+
+	_ = w.Flush()
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/thek4n/pomodoro/pkg/i18n"
+)
+
+// messages is the catalog of the language the client prints in, set once
+// the options are loaded.
+var messages = i18n.English()
+
+// printStatus prints the confirmation of a command, with the period and
+// the remaining time it left the timer at.
+func printStatus(key, period, remaining string) {
+	fmt.Printf(messages.Text("status."+key)+"\n", messages.Period(period), remaining)
+}
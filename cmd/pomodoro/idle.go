@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// reportIdle is meant for idle daemons like swayidle, the optional
+// duration is how long the user has already been away.
+func reportIdle(c *client.Client, args []string) {
+	request := protocol.Request{Cmd: "idle"}
+	if len(args) > 0 {
+		request.Args = map[string]string{"duration": args[0]}
+	}
+
+	response, err := c.Do(request)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStatus("idle", response.Status.Period, response.Status.RestOfTimeStr)
+}
+
+func reportActive(c *client.Client) {
+	response, err := c.Command("active")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStatus("active", response.Status.Period, response.Status.RestOfTimeStr)
+}
@@ -2,359 +2,570 @@
 package main
 
 import (
-	"encoding/json"
+	"cmp"
 	"fmt"
-	"net"
+	"log/slog"
 	"os"
-	"os/exec"
-	"path"
 	"strings"
-	"sync"
 	"time"
 
-	flags "github.com/jessevdk/go-flags"
+	"github.com/thek4n/pomodoro/internal/config"
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/daemon"
+	"github.com/thek4n/pomodoro/pkg/notify"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+	"golang.org/x/term"
 )
 
-type options struct {
-	SocketPath  string `long:"socket-path" default:"" env:"SOCKET_PATH" description:"Path to socket"`
-	WorkMinutes int    `long:"work" short:"w" default:"25" description:"Time period for work in minutes"`
-	RestMinutes int    `long:"rest" short:"r" default:"5" description:"Time period for rest in minutes"`
-}
+func newDaemonConfig(opts *config.Options, logger *slog.Logger) (daemon.Config, error) {
+	notifier, err := newNotifier(opts, logger)
+	if err != nil {
+		return daemon.Config{}, err
+	}
 
-func (opts *options) SetDefaultSocketPathIfNotProvided() {
-	if opts.SocketPath != "" {
-		return
+	cfg := daemon.Config{
+		SocketPath:   opts.SocketPath,
+		AuthToken:    opts.AuthToken,
+		WorkDuration: opts.WorkDuration(),
+		RestDuration: opts.RestDuration(),
+		WorkStep:     opts.WorkStep,
+		Prepare:      opts.Prepare,
+		Verbose:      opts.Verbose,
+		NotifyStart:  opts.NotifyStart,
+		Notifier:     notifier,
+		Tick:         opts.Tick,
+		WorkSound:    opts.WorkSound,
+		RestSound:    opts.RestSound,
+		SoundPlayer:  opts.SoundPlayer,
+		HistorySize:  opts.HistorySize,
+		MaxConns:     opts.MaxConns,
+		ConnTimeout:  opts.ConnTimeout,
+		Goal:         opts.Goal,
+		Logger:       logger,
+		ManualSwitch: opts.ManualSwitch,
+		AfterRest:    opts.AfterRest,
+		AfterWork:    opts.AfterWork,
+		WaitReminder: opts.WaitReminder,
+		OnSuspend:    opts.OnSuspend,
+		OneShot:      opts.OneShot,
+		RestOnly:     opts.RestOnly,
+
+		LongRestDuration:  opts.LongRestDuration(),
+		LongBreakInterval: opts.LongBreakInterval,
+
+		SnoozeDuration: opts.SnoozeDuration,
+		MaxSnooze:      opts.MaxSnooze,
+
+		MQTTBroker:          opts.MQTTBroker,
+		MQTTTopic:           opts.MQTTTopic,
+		MQTTTransitionTopic: opts.MQTTTransitionTopic,
+		MQTTGranularity:     opts.MQTTGranularity,
+
+		Webhooks: opts.Webhooks,
+
+		SlackToken:  opts.SlackToken,
+		SlackStatus: opts.SlackStatus,
+		SlackEmoji:  opts.SlackEmoji,
+
+		BusyCheckCmd:      opts.BusyCheckCmd,
+		BusyCheckInterval: opts.BusyCheckInterval,
+
+		IdleThreshold: opts.IdleThreshold,
+		IdleCmd:       opts.IdleCmd,
+
+		StrictBreaks: opts.StrictBreaks,
+		BreakLockCmd: opts.BreakLockCmd,
+
+		StatusFile:   opts.StatusFile,
+		StatusFormat: opts.StatusFormat,
+
+		HooksDir:    opts.HooksDir,
+		TaskCmd:     opts.TaskCmd,
+		HistoryFile: opts.HistoryFilePath(),
+		StateFile:   opts.StateFilePath(),
+		Restore:     opts.Restore,
+
+		Listen:         opts.Listen,
+		HTTPAddress:    opts.HTTP,
+		MetricsAddress: opts.Metrics,
+		DBus:           opts.DBus,
+		GnomePomodoro:  opts.Gnome,
 	}
 
-	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
-	if runtimeDir == "" {
-		runtimeDir = "/run"
+	timers, err := opts.TimerDefinitions()
+	if err != nil {
+		return daemon.Config{}, err
 	}
 
-	display := os.Getenv("DISPLAY")
-	if display == "" {
-		display = os.Getenv("WAYLAND_DISPLAY")
+	for _, timer := range timers {
+		cfg.Timers = append(cfg.Timers, daemon.TimerConfig{
+			Name:             timer.Name,
+			WorkDuration:     timer.Work,
+			RestDuration:     timer.Rest,
+			LongRestDuration: timer.LongRest,
+		})
 	}
 
-	if display == "" {
-		display = "0"
+	if opts.MQTTEveryTick {
+		cfg.MQTTGranularity = protocol.GranularitySecond
 	}
 
-	opts.SocketPath = path.Join(runtimeDir, fmt.Sprintf("pomodoro_%s.sock", display))
-}
+	if cfg.HooksDir == "" {
+		cfg.HooksDir = config.DefaultHooksDir()
+	}
 
-type Period int
+	if opts.StartPeriod != "" {
+		startPeriod, err := protocol.ParsePeriod(opts.StartPeriod)
+		if err != nil {
+			return daemon.Config{}, err
+		}
 
-const (
-	Unknown Period = iota
-	Work
-	Rest
-	Stopped
-)
+		cfg.StartPeriod = startPeriod
+		cfg.StartRemaining = opts.StartRemaining
+	}
 
-type Status struct {
-	Period        string        `json:"period"`
-	RestOfTime    time.Duration `json:"rest_of_time"`
-	RestOfTimeStr string        `json:"rest_of_time_str"`
-}
+	catalog, err := opts.Catalog()
+	if err != nil {
+		return daemon.Config{}, err
+	}
 
-type Response struct {
-	Status *Status `json:"status,omitempty"`
-	Error  string  `json:"error,omitempty"`
-}
+	cfg.Catalog = catalog
 
-type PomodoroDaemon struct {
-	mu                     sync.RWMutex
-	socketPath             string
-	currentPeriod          Period
-	currentRestOfTime      time.Duration
-	initialPeriodDurations map[Period]time.Duration
-}
+	// The texts not set in the options come from the catalog.
+	text := func(value, key string) string {
+		return cmp.Or(value, catalog.Text("notification."+key))
+	}
 
-func NewPomodoroDaemon(socketPath string, workDuration, restDuration time.Duration) *PomodoroDaemon {
-	return &PomodoroDaemon{
-		socketPath:		   socketPath,
-		currentPeriod:     Work,
-		currentRestOfTime: workDuration,
-		initialPeriodDurations: map[Period]time.Duration{
-			Work: workDuration,
-			Rest: restDuration,
-		},
+	workNotification, err := daemon.ParseNotificationTemplate("work", text(opts.WorkTitle, "work-title"), text(opts.WorkMessage, "work-message"))
+	if err != nil {
+		return daemon.Config{}, err
 	}
-}
 
-func (p *PomodoroDaemon) Start() error {
-	if _, err := os.Stat(p.socketPath); err == nil {
-		return fmt.Errorf("socket %s already exists", p.socketPath)
+	restNotification, err := daemon.ParseNotificationTemplate("rest", text(opts.RestTitle, "rest-title"), text(opts.RestMessage, "rest-message"))
+	if err != nil {
+		return daemon.Config{}, err
 	}
 
-	listener, err := net.Listen("unix", p.socketPath)
+	longRestNotification, err := daemon.ParseNotificationTemplate("long-rest", text(opts.LongRestTitle, "long-rest-title"), text(opts.LongRestMessage, "long-rest-message"))
 	if err != nil {
-		return fmt.Errorf("failed to create socket: %w", err)
+		return daemon.Config{}, err
 	}
-	defer p.removeExistingSocket()
-	defer listener.Close()
 
-	p.currentPeriod = Stopped
-	p.currentRestOfTime = 0
+	prepareNotification, err := daemon.ParseNotificationTemplate("prepare", text(opts.PrepareTitle, "prepare-title"), text(opts.PrepareMessage, "prepare-message"))
+	if err != nil {
+		return daemon.Config{}, err
+	}
 
-	go p.runTimer()
+	workNotification.Expire, workNotification.Urgency = notificationExpire(opts.WorkExpire), opts.WorkUrgency
+	restNotification.Expire, restNotification.Urgency = notificationExpire(opts.RestExpire), opts.RestUrgency
+	longRestNotification.Expire, longRestNotification.Urgency = notificationExpire(opts.LongRestExpire), opts.LongRestUrgency
+	prepareNotification.Expire, prepareNotification.Urgency = notificationExpire(opts.PrepareExpire), opts.PrepareUrgency
 
-	fmt.Printf("Daemon started, socket: %s\n", p.socketPath)
+	cfg.Notifications = map[protocol.Period]daemon.NotificationTemplate{
+		protocol.Work:     workNotification,
+		protocol.Rest:     restNotification,
+		protocol.LongRest: longRestNotification,
+		protocol.Prepare:  prepareNotification,
+	}
 
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			continue
-		}
+	cfg.WarningNotification, err = daemon.ParseNotificationTemplate("warning", text(opts.WarningTitle, "warning-title"), text(opts.WarningMessage, "warning-message"))
+	if err != nil {
+		return daemon.Config{}, err
+	}
 
-		go p.handleConnection(conn)
+	cfg.Warnings = map[protocol.Period][]time.Duration{
+		protocol.Work:     opts.WorkWarnings,
+		protocol.Rest:     opts.RestWarnings,
+		protocol.LongRest: opts.RestWarnings,
 	}
-}
 
-func (p *PomodoroDaemon) removeExistingSocket() {
-	_ = os.Remove(p.socketPath)
+	return cfg, nil
 }
 
-func (p *PomodoroDaemon) runTimer() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		p.mu.Lock()
+// notificationExpire maps the 0 of the expire options, which keeps the
+// notification until it is dismissed, to notify.NeverExpire.
+func notificationExpire(expire time.Duration) time.Duration {
+	if expire == 0 {
+		return notify.NeverExpire
+	}
 
-		if p.currentPeriod != Stopped && p.currentRestOfTime <= 1*time.Second {
-			p.switchTimer()
-		} else if p.currentPeriod != Stopped {
-			p.currentRestOfTime -= 1 * time.Second
-		}
+	return expire
+}
 
-		p.mu.Unlock()
-	}
+// getFormat holds how get prints the status.
+type getFormat struct {
+	format   string
+	template string
+	color    string
 }
 
-func (p *PomodoroDaemon) switchTimer() {
-	var title, message string
+func (f getFormat) render(status *protocol.Status) (string, error) {
+	if f.format == getFormatWaybar {
+		return formatWaybar(status)
+	}
 
-	p.currentPeriod = p.getReversedPeriod(p.currentPeriod)
-	p.currentRestOfTime = p.initialPeriodDurations[p.currentPeriod]
+	output := status.String()
 
-	args := []string{"-t", "5000", "-a", "Pomodoro Timer"}
+	if f.template != "" {
+		var err error
 
-	if p.currentPeriod == Work {
-		title = "Pomodoro: Work Time!"
-		message = "Time to focus! Start your work session."
-	} else {
-		title = "Pomodoro: Break Time!"
-		message = "Take a break and relax."
+		output, err = formatStatusTemplate(f.template, status)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	args = append(args, title, message)
+	if useColor(f.color) && !status.Paused {
+		output = colorize(output, status.PeriodCode)
+	}
 
-	cmd := exec.Command("notify-send", args...)
-	_ = cmd.Run()
+	return output, nil
 }
 
-func (p *PomodoroDaemon) handleConnection(conn net.Conn) {
-	defer conn.Close()
+func getFormatted(c *client.Client, format getFormat) {
+	response, err := c.Command("get")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	buf := make([]byte, 1024)
+	output, err := format.render(response.Status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	n, err := conn.Read(buf)
+	fmt.Println(output)
+}
+
+// getAllFormatted prints the status of every timer, each prefixed with its
+// name.
+func getAllFormatted(c *client.Client, format getFormat) {
+	response, err := c.Command("timers")
 	if err != nil {
-		return
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	command := strings.TrimSpace(string(buf[:n]))
+	for _, status := range response.Timers {
+		output, err := format.render(&status)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
-	var response Response
+		name := status.Timer
+		if name == "" {
+			name = "main"
+		}
 
-	switch command {
-	case "get":
-		status := p.getStatus()
-		response.Status = &status
-	case "switch":
-		p.toggleTimer()
-		status := p.getStatus()
-		response.Status = &status
-	default:
-		response.Error = "Unknown command"
+		fmt.Printf("%s: %s\n", name, output)
 	}
+}
 
-	jsonData, err := json.Marshal(response)
-	if err != nil {
-		return
+// getCount prints the number of work periods completed today. The field is
+// decoded as a pointer to tell a daemon that does not report it from zero.
+func getCount(c *client.Client) {
+	var response struct {
+		Status *struct {
+			CompletedToday *int `json:"completed_today"`
+		} `json:"status"`
+		Error string `json:"error"`
 	}
 
-	_, err = conn.Write(jsonData)
-	if err != nil {
-		return
+	if err := c.Exchange(protocol.Request{Cmd: "get"}, &response); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-}
 
-func (p *PomodoroDaemon) getStatus() Status {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
-	if p.currentPeriod == Stopped {
-		return Status{
-			Period:        "Stopped",
-			RestOfTime:    0,
-			RestOfTimeStr: "00:00",
-		}
+	if response.Error != "" {
+		fmt.Fprintf(os.Stderr, "Error: daemon error: %s\n", response.Error)
+		os.Exit(1)
 	}
 
-	return Status{
-		Period:        p.periodToString(p.currentPeriod),
-		RestOfTime:    p.currentRestOfTime,
-		RestOfTimeStr: formatDuration(p.currentRestOfTime),
+	if response.Status == nil || response.Status.CompletedToday == nil {
+		fmt.Fprintf(os.Stderr, "Error: daemon does not report completed sessions, it may be outdated\n")
+		os.Exit(1)
 	}
-}
 
-func (p *PomodoroDaemon) toggleTimer() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	fmt.Println(*response.Status.CompletedToday)
+}
 
-	if p.currentPeriod == Stopped {
-		p.currentPeriod = Work
-		p.currentRestOfTime = p.initialPeriodDurations[Work]
-	} else {
-		p.currentPeriod = Stopped
-		p.currentRestOfTime = 0
+func toggleTimer(c *client.Client) {
+	response, err := c.Command("switch")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+
+	printStatus("toggled", response.Status.Period, response.Status.RestOfTimeStr)
 }
 
-func (p *PomodoroDaemon) getReversedPeriod(current Period) Period {
-	if current == Work {
-		return Rest
+func continueTimer(c *client.Client) {
+	response, err := c.Command("continue")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	return Work
+	printStatus("continued", response.Status.Period, response.Status.RestOfTimeStr)
 }
 
-func (p *PomodoroDaemon) periodToString(period Period) string {
-	switch period {
-	case Work:
-		return "Work"
-	case Rest:
-		return "Rest"
-	case Stopped:
-		return "Stopped"
-	default:
-		return "Unknown"
+func pauseTimer(c *client.Client) {
+	response, err := c.Command("pause")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+
+	printStatus("paused", response.Status.PausedPeriod, response.Status.RestOfTimeStr)
 }
 
-func sendCommandToDaemon(command string, socketPath string) (*Response, error) {
-	conn, err := net.Dial("unix", socketPath)
+func resumeTimer(c *client.Client) {
+	response, err := c.Command("resume")
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to daemon: %w", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	defer conn.Close()
 
-	if _, err := conn.Write([]byte(command)); err != nil {
-		return nil, fmt.Errorf("error sending command: %w", err)
+	printStatus("resumed", response.Status.Period, response.Status.RestOfTimeStr)
+}
+
+func stopDaemon(c *client.Client) {
+	response, err := c.Command("shutdown")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	buf := make([]byte, 1024)
+	fmt.Println(response.Message)
+}
 
-	n, err := conn.Read(buf)
+func reloadDaemon(c *client.Client) {
+	response, err := c.Command("reload")
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	var response Response
-	if err := json.Unmarshal(buf[:n], &response); err != nil {
-		return nil, fmt.Errorf("error parsing JSON response: %w", err)
+	fmt.Println(response.Message)
+}
+
+// reloadDaemonConfig parses the command line and config file again, the
+// log stays as it was opened.
+func reloadDaemonConfig(logger *slog.Logger) (daemon.Config, error) {
+	opts, _, _, err := config.Load(os.Args)
+	if err != nil {
+		return daemon.Config{}, err
 	}
 
-	if response.Error != "" {
-		return nil, fmt.Errorf("daemon error: %s", response.Error)
+	if err := opts.Validate(); err != nil {
+		return daemon.Config{}, err
 	}
 
-	return &response, nil
+	return newDaemonConfig(opts, logger)
 }
 
-func getFormatted(socketPath string) {
-	response, err := sendCommandToDaemon("get", socketPath)
+func restartCycle(c *client.Client) {
+	response, err := c.Command("restart")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	var emoji string
+	printStatus("restarted", response.Status.Period, response.Status.RestOfTimeStr)
+}
 
-	switch response.Status.Period {
-	case "Work":
-		emoji = "🍅"
-	case "Rest":
-		emoji = "😋"
-	case "Stopped":
-		emoji = "⏸️"
-	default:
-		emoji = "❓"
+func resetPeriod(c *client.Client) {
+	response, err := c.Command("reset")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("%s %s\n", emoji, response.Status.RestOfTimeStr)
+	printStatus("reset", response.Status.Period, response.Status.RestOfTimeStr)
 }
 
-func toggleTimer(socketPath string) {
-	response, err := sendCommandToDaemon("switch", socketPath)
+func printConfig(c *client.Client) {
+	response, err := c.Command("config-get")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Timer toggled. Status: %s %s\n", response.Status.Period, response.Status.RestOfTimeStr)
+	printConfigValues(response.Config)
 }
 
-func formatDuration(d time.Duration) string {
-	seconds := int(d.Seconds())
-	hours := seconds / 3600
-	seconds %= 3600
-	minutes := seconds / 60
-	seconds %= 60
+func printConfigValues(config *protocol.Config) {
+	fmt.Printf("work: %s\n", protocol.FormatShortDuration(config.WorkDuration))
+	fmt.Printf("rest: %s\n", protocol.FormatShortDuration(config.RestDuration))
+
+	if config.LongBreakInterval > 0 {
+		fmt.Printf("long rest: %s every %d work periods\n",
+			protocol.FormatShortDuration(config.LongRestDuration),
+			config.LongBreakInterval,
+		)
+	}
 
-	if hours > 0 {
-		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	fmt.Printf("tick: %s\n", config.Tick)
+
+	if config.Goal > 0 {
+		fmt.Printf("goal: %d\n", config.Goal)
 	}
 
-	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+	switch {
+	case config.NotifyCommand != "":
+		fmt.Printf("notifier: %s: %s\n", config.Notifier, config.NotifyCommand)
+	case len(config.NotifyCmds) > 0:
+		fmt.Printf("notifier: %s: %s\n", config.Notifier, strings.Join(config.NotifyCmds, ", "))
+	case config.Notifier != "":
+		fmt.Printf("notifier: %s\n", config.Notifier)
+	}
 }
 
 func main() {
-	var opts options
-
-	args, err := flags.NewParser(&opts, flags.Default).ParseArgs(os.Args)
+	opts, parser, args, err := config.Load(os.Args)
 	if err != nil {
 		fmt.Printf("parse params error: %s\n", err)
 		os.Exit(1)
 	}
 
-	opts.SetDefaultSocketPathIfNotProvided()
+	messages, err = opts.Catalog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+		os.Exit(1)
+	}
 
 	if len(args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s daemon | get | toggle\n", args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s daemon [stop|reload] [socket] | get [socket] | toggle [socket] | start [--task ID] [tag] | continue | ack | pause | resume | skip | snooze | timer <duration> | mute <duration> | unmute | idle [duration] | active | set <period> <duration> | work-inc | work-dec | restart | reset | config | uptime | watch | tui | tray | subscribe | history | stats | stop-daemon | completion <shell>\n", args[0])
 		os.Exit(1)
 	}
 
 	command := args[1]
 
+	opts.SetSocketPathFromArgs(command, args[2:])
+	opts.SetDefaultSocketPathIfNotProvided()
+
+	c := &client.Client{Address: opts.SocketPath, Token: opts.AuthToken, Timeout: opts.ConnTimeout, Timer: opts.Timer}
+
+	if (command == "get" || command == "toggle") && !opts.NoAutostart {
+		if err := ensureDaemon(opts.SocketPath, opts.DaemonArgs(parser)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	switch command {
 	case "daemon":
-		daemon := NewPomodoroDaemon(
-			opts.SocketPath,
-			time.Duration(opts.WorkMinutes) * time.Minute,
-			time.Duration(opts.RestMinutes) * time.Minute,
-		)
-		if err := daemon.Start(); err != nil {
+		if len(args) > 2 && args[2] == "stop" {
+			stopDaemon(c)
+			break
+		}
+
+		if len(args) > 2 && args[2] == "reload" {
+			reloadDaemon(c)
+			break
+		}
+
+		if err := opts.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+			os.Exit(1)
+		}
+
+		logger, err := opts.Logger()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+			os.Exit(1)
+		}
+
+		cfg, err := newDaemonConfig(opts, logger)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+			os.Exit(1)
+		}
+
+		cfg.Reload = func() (daemon.Config, error) {
+			return reloadDaemonConfig(logger)
+		}
+
+		d := daemon.New(cfg)
+		d.ShutdownOnSignal()
+		d.ReloadOnSignal()
+
+		if err := d.Start(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error starting daemon: %v\n", err)
 			os.Exit(1)
 		}
 	case "get":
-		getFormatted(opts.SocketPath)
+		if opts.Count {
+			getCount(c)
+			break
+		}
+
+		format := getFormat{format: opts.Format, template: opts.Template, color: opts.Color}
+
+		switch {
+		case opts.All:
+			getAllFormatted(c, format)
+		case opts.Follow:
+			followFormatted(c, opts.Granularity, format, false)
+		case opts.Watch:
+			followFormatted(c, opts.Granularity, format, term.IsTerminal(int(os.Stdout.Fd())))
+		default:
+			getFormatted(c, format)
+		}
 	case "toggle":
-		toggleTimer(opts.SocketPath)
+		toggleTimer(c)
+	case "start":
+		startWork(c, opts.Task, args[2:])
+	case "continue", "ack":
+		continueTimer(c)
+	case "pause":
+		pauseTimer(c)
+	case "resume":
+		resumeTimer(c)
+	case "skip":
+		skipTimer(c)
+	case "snooze":
+		snoozeTimer(c)
+	case "timer":
+		startOneShotTimer(c, args[2:])
+	case "mute":
+		muteNotifications(c, args[2:])
+	case "unmute":
+		unmuteNotifications(c)
+	case "idle":
+		reportIdle(c, args[2:])
+	case "active":
+		reportActive(c)
+	case "set":
+		setDuration(c, args[2:])
+	case "work-inc", "work-dec":
+		adjustWorkDuration(c, command)
+	case "restart":
+		restartCycle(c)
+	case "reset":
+		resetPeriod(c)
+	case "config":
+		printConfig(c)
+	case "uptime":
+		printUptime(c)
+	case "watch":
+		watchStatus(c, opts.Granularity)
+	case "tui":
+		runTUI(c, opts.Color)
+	case "tray":
+		runTray(c)
+	case "subscribe":
+		subscribeStatus(c, opts.Granularity)
+	case "history":
+		printHistory(c)
+	case "stats":
+		printStats(opts.HistoryFilePath(), opts.JSON)
+	case "stop-daemon":
+		stopDaemon(c)
+	case "completion":
+		printCompletion(parser, args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		os.Exit(1)
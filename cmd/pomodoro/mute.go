@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func muteNotifications(c *client.Client, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: mute <duration>\n")
+		os.Exit(1)
+	}
+
+	request := protocol.Request{Cmd: "mute", Args: map[string]string{"duration": args[0]}}
+
+	response, err := c.Do(request)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(messages.Text("status.muted")+"\n", response.Status.MutedUntil.Format("15:04:05"))
+}
+
+func unmuteNotifications(c *client.Client) {
+	if _, err := c.Command("unmute"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(messages.Text("status.unmuted"))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/thek4n/pomodoro/internal/config"
+	"github.com/thek4n/pomodoro/pkg/notify"
+)
+
+// newNotifier returns the notifier of the options, a notification command
+// takes over from the backend.
+func newNotifier(opts *config.Options, logger *slog.Logger) (notify.Notifier, error) {
+	if opts.NotifyCommand != "" {
+		return notify.NewCommand(opts.NotifyCommand)
+	}
+
+	return notify.New(opts.Notifier, parseCommandList(opts.NotifyCmds), logger)
+}
+
+func parseCommandList(list string) []string {
+	var commands []string
+
+	for _, command := range strings.Split(list, ",") {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
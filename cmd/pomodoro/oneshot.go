@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func startOneShotTimer(c *client.Client, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: timer <duration> [work|rest]\n")
+		os.Exit(1)
+	}
+
+	requestArgs := map[string]string{"duration": args[0]}
+	if len(args) > 1 {
+		requestArgs["period"] = args[1]
+	}
+
+	response, err := c.Do(protocol.Request{Cmd: "timer", Args: requestArgs})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStatus("started", response.Status.Period, response.Status.RestOfTimeStr)
+}
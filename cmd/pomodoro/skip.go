@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+)
+
+func skipTimer(c *client.Client) {
+	response, err := c.Command("skip")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStatus("skipped", response.Status.Period, response.Status.RestOfTimeStr)
+}
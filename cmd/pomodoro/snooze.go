@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+)
+
+func snoozeTimer(c *client.Client) {
+	response, err := c.Command("snooze")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStatus("snoozed", response.Status.Period, response.Status.RestOfTimeStr)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func startWork(c *client.Client, task string, args []string) {
+	request := protocol.Request{Cmd: "start", Args: map[string]string{}}
+
+	if task != "" {
+		request.Args["task"] = task
+	}
+
+	if len(args) > 0 {
+		request.Args["tag"] = strings.Join(args, " ")
+	}
+
+	response, err := c.Do(request)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStatus("work-started", response.Status.Period, response.Status.RestOfTimeStr)
+}
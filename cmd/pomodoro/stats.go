@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+type StatsSummary struct {
+	Completed      int `json:"completed"`
+	FocusedMinutes int `json:"focused_minutes"`
+}
+
+type Stats struct {
+	Today StatsSummary `json:"today"`
+	Week  StatsSummary `json:"week"`
+	Month StatsSummary `json:"month"`
+
+	// Tags sums up the whole history by session tag.
+	Tags map[string]StatsSummary `json:"tags,omitempty"`
+}
+
+// statsFromHistoryFile sums up the work periods of the history file. Weeks
+// start on Monday.
+func statsFromHistoryFile(path string, now time.Time) (Stats, error) {
+	var stats Stats
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	now = now.Local()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var focusedToday, focusedWeek, focusedMonth time.Duration
+
+	focusedTags := make(map[string]time.Duration)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry protocol.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+
+		if entry.Period != "Work" {
+			continue
+		}
+
+		ended := entry.EndedAt
+		if ended.IsZero() {
+			ended = entry.StartedAt.Add(entry.Duration)
+		}
+
+		completed := 0
+		if !entry.Skipped {
+			completed = 1
+		}
+
+		focused := entry.Duration - entry.Idle
+
+		if !ended.Before(month) {
+			stats.Month.Completed += completed
+			focusedMonth += focused
+		}
+
+		if !ended.Before(week) {
+			stats.Week.Completed += completed
+			focusedWeek += focused
+		}
+
+		if !ended.Before(today) {
+			stats.Today.Completed += completed
+			focusedToday += focused
+		}
+
+		if entry.Tag != "" {
+			if stats.Tags == nil {
+				stats.Tags = make(map[string]StatsSummary)
+			}
+
+			summary := stats.Tags[entry.Tag]
+			summary.Completed += completed
+			stats.Tags[entry.Tag] = summary
+
+			focusedTags[entry.Tag] += focused
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	stats.Today.FocusedMinutes = int(focusedToday.Minutes())
+	stats.Week.FocusedMinutes = int(focusedWeek.Minutes())
+	stats.Month.FocusedMinutes = int(focusedMonth.Minutes())
+
+	for tag, focused := range focusedTags {
+		summary := stats.Tags[tag]
+		summary.FocusedMinutes = int(focused.Minutes())
+		stats.Tags[tag] = summary
+	}
+
+	return stats, nil
+}
+
+func printStats(historyFile string, asJSON bool) {
+	if historyFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: the history file is disabled\n")
+		os.Exit(1)
+	}
+
+	stats, err := statsFromHistoryFile(historyFile, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		_ = json.NewEncoder(os.Stdout).Encode(stats)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERIOD\tCOMPLETED\tFOCUSED")
+
+	for _, row := range []struct {
+		name    string
+		summary StatsSummary
+	}{
+		{"Today", stats.Today},
+		{"This week", stats.Week},
+		{"This month", stats.Month},
+	} {
+		fmt.Fprintf(w, "%s\t%d\t%s\n",
+			row.name,
+			row.summary.Completed,
+			formatMinutes(row.summary.FocusedMinutes),
+		)
+	}
+
+	_ = w.Flush()
+
+	if len(stats.Tags) == 0 {
+		return
+	}
+
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tCOMPLETED\tFOCUSED")
+
+	for _, tag := range slices.Sorted(maps.Keys(stats.Tags)) {
+		fmt.Fprintf(w, "%s\t%d\t%s\n",
+			tag,
+			stats.Tags[tag].Completed,
+			formatMinutes(stats.Tags[tag].FocusedMinutes),
+		)
+	}
+
+	_ = w.Flush()
+}
+
+func formatMinutes(minutes int) string {
+	if minutes == 0 {
+		return "0m"
+	}
+
+	return protocol.FormatShortDuration(time.Duration(minutes) * time.Minute)
+}
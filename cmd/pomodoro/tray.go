@@ -0,0 +1,476 @@
+//go:build !freebsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// The StatusNotifierItem and dbusmenu interfaces tray hosts like KDE,
+// waybar and the GNOME AppIndicator extension show icons for.
+const (
+	sniInterface       = "org.kde.StatusNotifierItem"
+	sniPath            = "/StatusNotifierItem"
+	sniWatcherName     = "org.kde.StatusNotifierWatcher"
+	sniWatcherPath     = "/StatusNotifierWatcher"
+	sniWatcherRegister = sniWatcherName + ".RegisterStatusNotifierItem"
+	menuInterface      = "com.canonical.dbusmenu"
+	menuPath           = "/MenuBar"
+	propsInterface     = "org.freedesktop.DBus.Properties"
+)
+
+// IDs of the tray menu items, 0 is the root.
+const (
+	menuToggle int32 = iota + 1
+	menuPause
+	menuSkip
+	menuSeparator
+	menuQuit
+)
+
+// tray shows the status in the system tray: left click toggles, the menu
+// pauses, skips and quits.
+type tray struct {
+	client *client.Client
+	conn   *dbus.Conn
+	quit   chan struct{}
+	once   sync.Once
+
+	mu       sync.Mutex
+	status   *protocol.Status
+	revision uint32
+}
+
+// runTray keeps a tray icon for the timer until Quit is chosen or the
+// daemon goes away.
+func runTray(c *client.Client) {
+	t := &tray{client: c, quit: make(chan struct{})}
+
+	if err := t.start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer t.conn.Close()
+
+	streamErr := make(chan error, 1)
+
+	go func() {
+		streamErr <- c.Subscribe(protocol.GranularitySecond, t.update)
+	}()
+
+	select {
+	case <-t.quit:
+	case err := <-streamErr:
+		if err == nil {
+			err = fmt.Errorf("daemon closed the connection")
+		}
+
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (t *tray) start() error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+
+	t.conn = conn
+
+	name := fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid())
+	if _, err := conn.RequestName(name, dbus.NameFlagDoNotQueue); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to request %s: %w", name, err)
+	}
+
+	if err := t.export(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	if err := conn.Object(sniWatcherName, sniWatcherPath).Call(sniWatcherRegister, 0, name).Err; err != nil {
+		conn.Close()
+		return fmt.Errorf("no system tray to show the icon in: %w", err)
+	}
+
+	return nil
+}
+
+func (t *tray) export() error {
+	item := &trayItem{t}
+	menu := &trayMenu{t}
+
+	exports := []struct {
+		value any
+		path  dbus.ObjectPath
+		iface string
+	}{
+		{item, sniPath, sniInterface},
+		{trayProperties{t}, sniPath, propsInterface},
+		{menu, menuPath, menuInterface},
+		{trayProperties{t}, menuPath, propsInterface},
+	}
+
+	for _, export := range exports {
+		if err := t.conn.Export(export.value, export.path, export.iface); err != nil {
+			return fmt.Errorf("failed to export %s: %w", export.iface, err)
+		}
+	}
+
+	nodes := []*introspect.Node{
+		{
+			Name: sniPath,
+			Interfaces: []introspect.Interface{prop.IntrospectData, {
+				Name:    sniInterface,
+				Methods: introspect.Methods(item),
+				Signals: []introspect.Signal{{Name: "NewIcon"}, {Name: "NewToolTip"}, {Name: "NewTitle"}},
+			}},
+		},
+		{
+			Name: menuPath,
+			Interfaces: []introspect.Interface{prop.IntrospectData, {
+				Name:    menuInterface,
+				Methods: introspect.Methods(menu),
+				Signals: []introspect.Signal{{
+					Name: "LayoutUpdated",
+					Args: []introspect.Arg{{Name: "revision", Type: "u"}, {Name: "parent", Type: "i"}},
+				}},
+			}},
+		},
+	}
+
+	for _, node := range nodes {
+		if err := t.conn.Export(introspect.NewIntrospectable(node), dbus.ObjectPath(node.Name), "org.freedesktop.DBus.Introspectable"); err != nil {
+			return fmt.Errorf("failed to export introspection data: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// update takes a status from the daemon and tells the tray host what
+// changed. The menu only changes when pausing flips the pause item.
+func (t *tray) update(status *protocol.Status) {
+	t.mu.Lock()
+	previous := t.status
+	t.status = status
+
+	layoutChanged := previous == nil || previous.Paused != status.Paused
+	if layoutChanged {
+		t.revision++
+	}
+
+	revision := t.revision
+	t.mu.Unlock()
+
+	t.emit(sniPath, sniInterface+".NewToolTip")
+	t.emit(sniPath, sniInterface+".NewTitle")
+
+	if previous == nil || trayIcon(previous) != trayIcon(status) {
+		t.emit(sniPath, sniInterface+".NewIcon")
+	}
+
+	if layoutChanged {
+		t.emit(menuPath, menuInterface+".LayoutUpdated", revision, int32(0))
+	}
+}
+
+func (t *tray) emit(path dbus.ObjectPath, name string, values ...any) {
+	_ = t.conn.Emit(path, name, values...)
+}
+
+func (t *tray) currentStatus() *protocol.Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status
+}
+
+// run sends a command chosen in the tray off the D-Bus handler, failures
+// are only logged as the tray has nowhere to show them.
+func (t *tray) run(command func() (*protocol.Status, error)) {
+	go func() {
+		if _, err := command(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}()
+}
+
+// trayIcon picks an icon of the freedesktop icon naming spec for the
+// status.
+func trayIcon(status *protocol.Status) string {
+	switch {
+	case status.Paused:
+		return "media-playback-pause"
+	case status.PeriodCode == protocol.Work || status.PeriodCode == protocol.Prepare:
+		return "appointment-soon"
+	case status.PeriodCode == protocol.Rest || status.PeriodCode == protocol.LongRest:
+		return "face-smile"
+	default:
+		return "media-playback-stop"
+	}
+}
+
+// trayItem implements org.kde.StatusNotifierItem.
+type trayItem struct {
+	t *tray
+}
+
+func (i *trayItem) Activate(x, y int32) *dbus.Error {
+	i.t.run(i.t.client.Toggle)
+	return nil
+}
+
+func (i *trayItem) SecondaryActivate(x, y int32) *dbus.Error {
+	return i.togglePause()
+}
+
+func (i *trayItem) ContextMenu(x, y int32) *dbus.Error {
+	return nil
+}
+
+func (i *trayItem) Scroll(delta int32, orientation string) *dbus.Error {
+	return nil
+}
+
+func (i *trayItem) togglePause() *dbus.Error {
+	command := i.t.client.Pause
+	if status := i.t.currentStatus(); status != nil && status.Paused {
+		command = i.t.client.Resume
+	}
+
+	i.t.run(command)
+
+	return nil
+}
+
+// toolTip is the (sa(iiay)ss) tooltip of a StatusNotifierItem: an icon
+// name, icon pixmaps, a title and a description.
+type toolTip struct {
+	IconName    string
+	IconPixmap  []iconPixmap
+	Title       string
+	Description string
+}
+
+type iconPixmap struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+func (t *tray) itemProps() map[string]dbus.Variant {
+	status := t.currentStatus()
+
+	title, description, icon, label := "Pomodoro", "", "media-playback-stop", ""
+
+	if status != nil {
+		period := messages.Period(status.Period)
+		if status.Paused && status.PausedPeriod != "" {
+			period = fmt.Sprintf(messages.Text("status.period-paused"), messages.Period(status.PausedPeriod))
+		}
+
+		description = fmt.Sprintf("%s %s\n%s", period, status.RestOfTimeStr,
+			fmt.Sprintf(messages.Text("status.completed-today"), status.GoalProgress()))
+		icon = trayIcon(status)
+		label = status.String()
+	}
+
+	return map[string]dbus.Variant{
+		"Category":      dbus.MakeVariant("ApplicationStatus"),
+		"Id":            dbus.MakeVariant("pomodoro"),
+		"Title":         dbus.MakeVariant(title),
+		"Status":        dbus.MakeVariant("Active"),
+		"IconName":      dbus.MakeVariant(icon),
+		"ToolTip":       dbus.MakeVariant(toolTip{IconName: icon, Title: title, Description: description}),
+		"ItemIsMenu":    dbus.MakeVariant(false),
+		"Menu":          dbus.MakeVariant(dbus.ObjectPath(menuPath)),
+		"XAyatanaLabel": dbus.MakeVariant(label),
+	}
+}
+
+// trayMenu implements the com.canonical.dbusmenu menu of the item.
+type trayMenu struct {
+	t *tray
+}
+
+// menuLayout is the (ia{sv}av) layout of a menu item and its children.
+type menuLayout struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+	Children   []dbus.Variant
+}
+
+type menuItemProperties struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+}
+
+type menuEvent struct {
+	ID        int32
+	EventID   string
+	Data      dbus.Variant
+	Timestamp uint32
+}
+
+func (t *tray) menuItems() []menuItemProperties {
+	pause := messages.Text("tray.pause")
+	if status := t.currentStatus(); status != nil && status.Paused {
+		pause = messages.Text("tray.resume")
+	}
+
+	label := func(text string) map[string]dbus.Variant {
+		return map[string]dbus.Variant{"label": dbus.MakeVariant(text)}
+	}
+
+	return []menuItemProperties{
+		{menuToggle, label(messages.Text("tray.toggle"))},
+		{menuPause, label(pause)},
+		{menuSkip, label(messages.Text("tray.skip"))},
+		{menuSeparator, map[string]dbus.Variant{"type": dbus.MakeVariant("separator")}},
+		{menuQuit, label(messages.Text("tray.quit"))},
+	}
+}
+
+func (m *trayMenu) GetLayout(parentID int32, recursionDepth int32, propertyNames []string) (uint32, menuLayout, *dbus.Error) {
+	m.t.mu.Lock()
+	revision := m.t.revision
+	m.t.mu.Unlock()
+
+	var children []dbus.Variant
+
+	for _, item := range m.t.menuItems() {
+		children = append(children, dbus.MakeVariant(menuLayout{ID: item.ID, Properties: item.Properties, Children: []dbus.Variant{}}))
+	}
+
+	root := menuLayout{
+		ID:         0,
+		Properties: map[string]dbus.Variant{"children-display": dbus.MakeVariant("submenu")},
+		Children:   children,
+	}
+
+	if parentID != 0 {
+		for _, child := range children {
+			if layout := child.Value().(menuLayout); layout.ID == parentID {
+				return revision, layout, nil
+			}
+		}
+	}
+
+	return revision, root, nil
+}
+
+func (m *trayMenu) GetGroupProperties(ids []int32, propertyNames []string) ([]menuItemProperties, *dbus.Error) {
+	var result []menuItemProperties
+
+	for _, item := range m.t.menuItems() {
+		for _, id := range ids {
+			if item.ID == id {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (m *trayMenu) GetProperty(id int32, name string) (dbus.Variant, *dbus.Error) {
+	for _, item := range m.t.menuItems() {
+		if value, ok := item.Properties[name]; ok && item.ID == id {
+			return value, nil
+		}
+	}
+
+	return dbus.Variant{}, prop.ErrPropNotFound
+}
+
+func (m *trayMenu) Event(id int32, eventID string, data dbus.Variant, timestamp uint32) *dbus.Error {
+	if eventID != "clicked" {
+		return nil
+	}
+
+	switch id {
+	case menuToggle:
+		m.t.run(m.t.client.Toggle)
+	case menuPause:
+		return (&trayItem{m.t}).togglePause()
+	case menuSkip:
+		m.t.run(m.t.client.Skip)
+	case menuQuit:
+		m.t.once.Do(func() { close(m.t.quit) })
+	}
+
+	return nil
+}
+
+func (m *trayMenu) EventGroup(events []menuEvent) ([]int32, *dbus.Error) {
+	for _, event := range events {
+		if err := m.Event(event.ID, event.EventID, event.Data, event.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	return []int32{}, nil
+}
+
+func (m *trayMenu) AboutToShow(id int32) (bool, *dbus.Error) {
+	return false, nil
+}
+
+func (m *trayMenu) AboutToShowGroup(ids []int32) ([]int32, []int32, *dbus.Error) {
+	return []int32{}, []int32{}, nil
+}
+
+func menuProps() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Version":       dbus.MakeVariant(uint32(3)),
+		"TextDirection": dbus.MakeVariant("ltr"),
+		"Status":        dbus.MakeVariant("normal"),
+		"IconThemePath": dbus.MakeVariant([]string{}),
+	}
+}
+
+// trayProperties implements org.freedesktop.DBus.Properties for the item
+// and the menu, reading the values from the last status.
+type trayProperties struct {
+	t *tray
+}
+
+func (p trayProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	value, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, prop.ErrPropNotFound
+	}
+
+	return value, nil
+}
+
+func (p trayProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	switch iface {
+	case sniInterface:
+		return p.t.itemProps(), nil
+	case menuInterface:
+		return menuProps(), nil
+	default:
+		return nil, prop.ErrIfaceNotFound
+	}
+}
+
+func (p trayProperties) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	return prop.ErrReadOnly
+}
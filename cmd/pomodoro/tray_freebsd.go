@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+)
+
+// runTray fails on FreeBSD, the tray icon needs the session bus and godbus
+// does not build there.
+func runTray(c *client.Client) {
+	fmt.Fprintln(os.Stderr, "Error: the tray is not supported on FreeBSD")
+	os.Exit(1)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+	"golang.org/x/term"
+)
+
+const (
+	ansiClear       = "\x1b[H\x1b[2J"
+	ansiAltScreen   = "\x1b[?1049h\x1b[?25l"
+	ansiMainScreen  = "\x1b[?25h\x1b[?1049l"
+	ansiBold        = "\x1b[1m"
+	tuiProgressSize = 40
+	keyCtrlC        = 0x03
+)
+
+// bigGlyphs draws the countdown five rows high.
+var bigGlyphs = map[rune][5]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {" █ ", "██ ", " █ ", " █ ", "███"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", "███", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", "  █", "  █", "  █"},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+	':': {" ", "█", " ", "█", " "},
+	'+': {"   ", " █ ", "███", " █ ", "   "},
+}
+
+// tui is the state of the terminal UI, updated by the status stream and
+// the keys pressed.
+type tui struct {
+	client  *client.Client
+	color   bool
+	status  *protocol.Status
+	message string
+}
+
+// runTUI shows a full screen countdown driven by the subscribe stream, with
+// keys for the common commands, until q is pressed.
+func runTUI(c *client.Client, colorMode string) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Error: tui needs a terminal\n")
+		os.Exit(1)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(ansiAltScreen)
+
+	restore := func() {
+		fmt.Print(ansiMainScreen)
+		_ = term.Restore(fd, state)
+	}
+
+	statuses := make(chan *protocol.Status)
+	streamErr := make(chan error, 1)
+
+	go func() {
+		streamErr <- c.Subscribe(protocol.GranularitySecond, func(status *protocol.Status) {
+			statuses <- status
+		})
+	}()
+
+	keys := make(chan byte)
+
+	go func() {
+		buf := make([]byte, 16)
+
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+
+			for _, key := range buf[:n] {
+				keys <- key
+			}
+		}
+	}()
+
+	ui := &tui{client: c, color: useColor(colorMode)}
+
+	for {
+		select {
+		case status := <-statuses:
+			ui.status = status
+		case err := <-streamErr:
+			restore()
+
+			if err == nil {
+				err = fmt.Errorf("daemon closed the connection")
+			}
+
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		case key, ok := <-keys:
+			if !ok || key == 'q' || key == keyCtrlC {
+				restore()
+				return
+			}
+
+			ui.handleKey(key)
+		}
+
+		ui.render()
+	}
+}
+
+func (ui *tui) handleKey(key byte) {
+	var err error
+
+	switch key {
+	case ' ', 't':
+		_, err = ui.client.Toggle()
+	case 'p':
+		if ui.status != nil && ui.status.Paused {
+			_, err = ui.client.Resume()
+		} else {
+			_, err = ui.client.Pause()
+		}
+	case 's':
+		_, err = ui.client.Skip()
+	case 'a', 'c':
+		_, err = ui.client.Command("continue")
+	default:
+		return
+	}
+
+	ui.message = ""
+	if err != nil {
+		ui.message = err.Error()
+	}
+}
+
+func (ui *tui) render() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	var lines []string
+
+	if status := ui.status; status != nil {
+		period := messages.Period(status.Period)
+		if status.Paused && status.PausedPeriod != "" {
+			period = fmt.Sprintf(messages.Text("status.period-paused"), messages.Period(status.PausedPeriod))
+		}
+
+		if status.Tag != "" {
+			period += " · " + status.Tag
+		}
+
+		lines = append(lines, status.Emoji()+" "+ansiBold+period+ansiReset, "")
+
+		for _, row := range bigCountdown(status.RestOfTimeStr) {
+			if ui.color {
+				row = colorize(row, status.PeriodCode)
+			}
+
+			lines = append(lines, row)
+		}
+
+		lines = append(lines,
+			"",
+			progressBar(status.ProgressPercent, min(tuiProgressSize, width-4)),
+			"",
+			fmt.Sprintf(messages.Text("status.completed-today"), status.GoalProgress()),
+		)
+
+		if status.SessionsUntilLongBreak > 0 {
+			lines = append(lines, fmt.Sprintf(messages.Text("status.long-break-in"), status.SessionsUntilLongBreak))
+		}
+	}
+
+	lines = append(lines, "", ui.message)
+
+	var sb strings.Builder
+
+	sb.WriteString(ansiClear)
+
+	for range max((height-len(lines)-2)/2, 0) {
+		sb.WriteString("\r\n")
+	}
+
+	for _, line := range lines {
+		sb.WriteString(center(line, width) + "\r\n")
+	}
+
+	fmt.Fprintf(&sb, "\x1b[%d;1H%s", height, center(messages.Text("tui.help"), width))
+
+	fmt.Print(sb.String())
+}
+
+// bigCountdown renders a remaining time like 24:59 or +02:31 in big
+// glyphs, characters without a glyph are left out.
+func bigCountdown(text string) []string {
+	rows := make([]string, 5)
+
+	for _, char := range text {
+		glyph, ok := bigGlyphs[char]
+		if !ok {
+			continue
+		}
+
+		for i := range rows {
+			rows[i] += glyph[i] + " "
+		}
+	}
+
+	return rows
+}
+
+func progressBar(percent, size int) string {
+	size = max(size, 10)
+	filled := min(max(percent, 0), 100) * size / 100
+
+	return fmt.Sprintf("[%s%s] %3d%%", strings.Repeat("█", filled), strings.Repeat("░", size-filled), percent)
+}
+
+// center pads the line to the middle of the screen, escape sequences do
+// not take up room.
+func center(line string, width int) string {
+	visible := len([]rune(stripANSI(line)))
+
+	return strings.Repeat(" ", max((width-visible)/2, 0)) + line
+}
+
+func stripANSI(text string) string {
+	var sb strings.Builder
+
+	inEscape := false
+
+	for _, char := range text {
+		switch {
+		case char == '\x1b':
+			inEscape = true
+		case inEscape:
+			inEscape = char < '@' || char > '~' || char == '['
+		default:
+			sb.WriteRune(char)
+		}
+	}
+
+	return sb.String()
+}
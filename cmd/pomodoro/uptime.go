@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+)
+
+func printUptime(c *client.Client) {
+	response, err := c.Command("uptime")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("up %s, since %s\n",
+		response.Uptime.Duration.Truncate(time.Second),
+		response.Uptime.StartedAt.Local().Format("2006-01-02 15:04:05"),
+	)
+}
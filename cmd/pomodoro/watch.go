@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func watchStatus(c *client.Client, granularity string) {
+	stream(c, granularity, func(status *protocol.Status) {
+		fmt.Println(status.String())
+	})
+}
+
+// followFormatted keeps printing the status in the get format, rewriting
+// the line in place when inPlace is set and one line per update otherwise.
+// Updates that render the same as the previous one are skipped.
+func followFormatted(c *client.Client, granularity string, format getFormat, inPlace bool) {
+	if inPlace {
+		defer fmt.Println()
+	}
+
+	var last string
+
+	stream(c, granularity, func(status *protocol.Status) {
+		output, err := format.render(status)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if output == last {
+			return
+		}
+
+		last = output
+
+		if inPlace {
+			fmt.Print("\r\x1b[K" + output)
+			return
+		}
+
+		fmt.Println(output)
+	})
+}
+
+// subscribeStatus prints every status update as a JSON line.
+func subscribeStatus(c *client.Client, granularity string) {
+	stream(c, granularity, func(status *protocol.Status) {
+		data, err := json.Marshal(status)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: error encoding status: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(string(data))
+	})
+}
+
+func stream(c *client.Client, granularity string, handle func(*protocol.Status)) {
+	if err := c.Subscribe(granularity, handle); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const getFormatWaybar = "waybar"
+
+// waybarOutput is the JSON a Waybar custom module with return-type json
+// expects.
+type waybarOutput struct {
+	Text       string `json:"text"`
+	Class      string `json:"class"`
+	Tooltip    string `json:"tooltip"`
+	Percentage int    `json:"percentage"`
+}
+
+func waybarClass(status *protocol.Status) string {
+	if status.Paused {
+		return "paused"
+	}
+
+	switch status.PeriodCode {
+	case protocol.Work:
+		return "work"
+	case protocol.Rest:
+		return "rest"
+	case protocol.LongRest:
+		return "long-rest"
+	case protocol.Prepare:
+		return "prepare"
+	case protocol.Waiting:
+		return "waiting"
+	case protocol.Stopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+func waybarTooltip(status *protocol.Status) string {
+	var lines []string
+
+	period := messages.Period(status.Period)
+	if status.Paused && status.PausedPeriod != "" {
+		period = fmt.Sprintf(messages.Text("status.period-paused"), messages.Period(status.PausedPeriod))
+	}
+
+	lines = append(lines, fmt.Sprintf("%s: %s", period, status.RestOfTimeStr))
+
+	lines = append(lines, fmt.Sprintf(messages.Text("status.completed-today"), status.GoalProgress()))
+
+	if status.SessionsUntilLongBreak > 0 {
+		lines = append(lines, fmt.Sprintf(messages.Text("status.long-break-in"), status.SessionsUntilLongBreak))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatWaybar(status *protocol.Status) (string, error) {
+	data, err := json.Marshal(waybarOutput{
+		Text:       status.String(),
+		Class:      waybarClass(status),
+		Tooltip:    waybarTooltip(status),
+		Percentage: status.ProgressPercent,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
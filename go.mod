@@ -2,6 +2,12 @@ module github.com/thek4n/pomodoro
 
 go 1.25.3
 
-require github.com/jessevdk/go-flags v1.6.1
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/Microsoft/go-winio v0.6.2
+	github.com/godbus/dbus/v5 v5.1.0
+	github.com/jessevdk/go-flags v1.6.1
+	golang.org/x/term v0.36.0
+)
 
-require golang.org/x/sys v0.21.0 // indirect
+require golang.org/x/sys v0.37.0 // indirect
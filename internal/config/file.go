@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// DefaultFilePath is the config file used when --config is not given.
+func DefaultFilePath() string {
+	return xdgPath("XDG_CONFIG_HOME", ".config", "config.toml")
+}
+
+// DefaultHooksDir is the hooks directory used when --hooks-dir is not given.
+func DefaultHooksDir() string {
+	return xdgPath("XDG_CONFIG_HOME", ".config", "hooks")
+}
+
+// DefaultHistoryFile is the history file used when --history-file is not
+// given.
+func DefaultHistoryFile() string {
+	return xdgPath("XDG_DATA_HOME", filepath.Join(".local", "share"), "history.jsonl")
+}
+
+// DefaultStateFile is the state file used when --state-file is not given.
+func DefaultStateFile() string {
+	return xdgPath("XDG_STATE_HOME", filepath.Join(".local", "state"), "state.json")
+}
+
+// xdgPath joins name to the pomodoro directory below the XDG base directory
+// of env, which falls back to fallback in the home directory.
+func xdgPath(env, fallback, name string) string {
+	dir := os.Getenv(env)
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, fallback)
+	}
+
+	return filepath.Join(dir, "pomodoro", name)
+}
+
+// Load parses the command line and fills the options it leaves unset from
+// the config file, so values are layered as defaults, config file,
+// environment and flags. It returns the parser and the remaining arguments.
+func Load(args []string) (*Options, *flags.Parser, []string, error) {
+	opts := &Options{}
+	parser := flags.NewParser(opts, flags.Default)
+
+	rest, err := parser.ParseArgs(args)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	path := opts.ConfigFile
+	if path == "" {
+		path = DefaultFilePath()
+	}
+
+	if err := opts.applyFile(parser, path, opts.ConfigFile != ""); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return opts, parser, rest, nil
+}
+
+// applyFile sets the options named by the keys of the file, which are the
+// long flag names. A missing file is only an error when it was requested
+// explicitly.
+func (opts *Options) applyFile(parser *flags.Parser, path string, required bool) error {
+	if path == "" {
+		return nil
+	}
+
+	var values map[string]any
+
+	if _, err := toml.DecodeFile(path, &values); err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return nil
+		}
+
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+
+	opts.fromFile = make(map[string]bool)
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		option := parser.FindOptionByLongName(key)
+		if option == nil || key == "config" {
+			return fmt.Errorf("%s: unknown option %q", path, key)
+		}
+
+		if setOnCommandLine(option) || envIsSet(option) {
+			continue
+		}
+
+		items, ok := values[key].([]any)
+		if !ok {
+			items = []any{values[key]}
+		} else if option.Field().Type.Kind() != reflect.Slice {
+			return fmt.Errorf("%s: option %q takes a single value", path, key)
+		}
+
+		for _, item := range items {
+			var value string
+
+			switch v := item.(type) {
+			case string:
+				value = v
+			case bool, int64, float64:
+				value = fmt.Sprint(v)
+			default:
+				return fmt.Errorf("%s: option %q must be a string, number, boolean or array of them", path, key)
+			}
+
+			if err := option.Set(&value); err != nil {
+				return fmt.Errorf("%s: invalid value for %q: %w", path, key, err)
+			}
+		}
+
+		opts.fromFile[key] = true
+	}
+
+	return nil
+}
+
+// clientOptions only change what a client command does, a daemon started
+// on behalf of a client does not get them.
+var clientOptions = map[string]bool{
+	"socket-path":  true,
+	"no-autostart": true,
+	"timer":        true,
+	"granularity":  true,
+	"watch":        true,
+	"follow":       true,
+	"count":        true,
+	"all":          true,
+	"task":         true,
+	"format":       true,
+	"template":     true,
+	"json":         true,
+	"color":        true,
+}
+
+// DaemonArgs returns the daemon options given on the command line as
+// flags, to start a daemon configured like the client was run. The config
+// file and the environment are left to the daemon to read itself.
+func (opts *Options) DaemonArgs(parser *flags.Parser) []string {
+	var args []string
+
+	for _, option := range allOptions(parser.Group) {
+		name := option.LongName
+		if name == "" || clientOptions[name] || opts.fromFile[name] || !setOnCommandLine(option) {
+			continue
+		}
+
+		value := reflect.ValueOf(option.Value())
+
+		if value.Kind() == reflect.Bool {
+			if value.Bool() {
+				args = append(args, "--"+name)
+			}
+
+			continue
+		}
+
+		if value.Kind() != reflect.Slice {
+			args = append(args, fmt.Sprintf("--%s=%v", name, value))
+			continue
+		}
+
+		for i := range value.Len() {
+			args = append(args, fmt.Sprintf("--%s=%v", name, value.Index(i)))
+		}
+	}
+
+	return args
+}
+
+func allOptions(group *flags.Group) []*flags.Option {
+	options := group.Options()
+
+	for _, child := range group.Groups() {
+		options = append(options, allOptions(child)...)
+	}
+
+	return options
+}
+
+// setOnCommandLine tells flags from defaults, which go-flags also reports
+// as set.
+func setOnCommandLine(option *flags.Option) bool {
+	return option.IsSet() && !option.IsSetDefault()
+}
+
+func envIsSet(option *flags.Option) bool {
+	if option.EnvKeyWithNamespace() == "" {
+		return false
+	}
+
+	_, ok := os.LookupEnv(option.EnvKeyWithNamespace())
+
+	return ok
+}
@@ -0,0 +1,417 @@
+// Package config holds the command line options of pomodoro and loads them
+// from the config file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/i18n"
+)
+
+// Options are the command line options, optionally layered on top of a
+// config file.
+type Options struct {
+	ConfigFile string `long:"config" env:"POMODORO_CONFIG" description:"Path to the config file (default: $XDG_CONFIG_HOME/pomodoro/config.toml)"`
+
+	SocketPath  string        `long:"socket-path" default:"" env:"SOCKET_PATH" description:"Path to socket, or tcp://127.0.0.1:PORT, or \\\\.\\pipe\\NAME on Windows; takes precedence over the positional socket argument of daemon, get and toggle"`
+	AuthToken   string        `long:"auth-token" env:"POMODORO_AUTH_TOKEN" description:"Token the daemon requires from clients and clients send with requests"`
+	Listen      string        `long:"listen" description:"Also accept clients on tcp://HOST:PORT, reachable from other machines; requires --auth-token"`
+	DBus        bool          `long:"dbus" description:"Expose the daemon on the session bus as org.thek4n.Pomodoro"`
+	Gnome       bool          `long:"gnome-pomodoro" description:"Also provide gnome-pomodoro's org.gnome.Pomodoro interface on the session bus, for extensions written for it"`
+	HTTP        string        `long:"http" description:"Serve a REST API on HOST:PORT: GET /status, POST /toggle, /pause, /resume, /skip and GET /events as server-sent events, and a dashboard at /"`
+	Metrics     string        `long:"metrics" description:"Serve Prometheus metrics on HOST:PORT at /metrics"`
+	WorkMinutes int           `long:"work" short:"w" default:"25" description:"Time period for work in minutes"`
+	RestMinutes int           `long:"rest" short:"r" default:"5" description:"Time period for rest in minutes"`
+	WorkStep    time.Duration `long:"work-step" default:"5m" description:"Change of the work duration by work-inc and work-dec"`
+	Verbose     bool          `long:"verbose" short:"v" description:"Print every period switch to stdout"`
+	Quiet       bool          `long:"quiet" short:"q" description:"Only log warnings and errors, same as --log-level=warn"`
+	NotifyStart bool          `long:"notify-start" description:"Send a notification once the daemon is ready"`
+	NotifyCmds  string        `long:"notify-cmds" default:"notify-send" description:"Comma-separated notification commands, tried in order until one succeeds"`
+	Notifier    string        `long:"notifier" default:"auto" choice:"auto" choice:"exec" choice:"dbus" choice:"macos" choice:"windows" choice:"none" description:"Notification backend: exec runs the notify-cmds, dbus talks to the session bus directly, macos uses terminal-notifier or osascript, windows shows toasts, none disables notifications, auto picks the native one"`
+	Tick        time.Duration `long:"tick" default:"1s" description:"Timer resolution"`
+	WorkSound   string        `long:"work-sound" description:"Audio file played when a work period starts, or beep for a built-in tone"`
+	RestSound   string        `long:"rest-sound" description:"Audio file played when a rest period starts, or beep for a built-in tone"`
+	SoundPlayer string        `long:"sound-player" description:"Command used to play sounds (default: paplay, aplay or afplay)"`
+	HistorySize int           `long:"history-size" default:"100" description:"Number of finished periods kept in history"`
+	MaxConns    int           `long:"max-connections" default:"128" description:"Maximum number of concurrent client connections"`
+	ConnTimeout time.Duration `long:"conn-timeout" default:"10s" description:"Deadline for sending a request and receiving its response"`
+	NoAutostart bool          `long:"no-autostart" description:"Make get and toggle fail instead of starting the daemon when it is not running"`
+	Goal        int           `long:"goal" default:"0" description:"Number of work periods to aim for each day, 0 disables the goal"`
+
+	NotifyCommand string `long:"notify-command" description:"Shell command template run for every notification instead of the notifier, e.g. 'dunstify -u {{.Urgency}} {{.Title}} {{.Message}}'; {{.Title}}, {{.Message}} and {{.Period}} are quoted, {{.Expire}} is in milliseconds"`
+
+	Timers []string `long:"define-timer" description:"Named timer with its own durations, cycle and history, as NAME=WORK/REST[/LONG-REST] like study=50m/10m; can be given more than once"`
+	Timer  string   `long:"timer" description:"Named timer commands act on (default: the main timer)"`
+
+	StartPeriod    string        `long:"start-period" description:"Start the daemon in this period (work or rest) instead of stopped"`
+	StartRemaining time.Duration `long:"start-remaining" description:"Remaining time of the start period (default: its full duration)"`
+
+	ManualSwitch bool          `long:"manual-switch" description:"Wait for confirmation before starting the next period"`
+	AfterRest    string        `long:"after-rest" default:"work" choice:"work" choice:"stop" choice:"prompt" description:"What happens when a rest period ends: start work, stop, or wait for continue"`
+	AfterWork    string        `long:"after-work" default:"rest" choice:"rest" choice:"prompt" description:"What happens when a work period ends: start the break, or wait for ack"`
+	WaitReminder time.Duration `long:"wait-reminder" default:"1m" description:"How often the notification is repeated while waiting for ack or continue, 0 disables it"`
+	OnSuspend    string        `long:"on-suspend" default:"count" choice:"count" choice:"pause" description:"What happens to a running period while the machine sleeps: the sleep counts toward it, or it is paused"`
+	Prepare      time.Duration `long:"prepare" default:"0s" description:"Short get-ready phase before each work period, 0 disables it"`
+	OneShot      bool          `long:"one-shot" description:"Stop after a single work period instead of cycling"`
+	RestOnly     bool          `long:"rest-only" description:"Run a single standalone rest period instead of cycling"`
+
+	LongRestMinutes   int `long:"long-rest" default:"15" description:"Time period for the long rest in minutes"`
+	LongBreakInterval int `long:"long-break-interval" default:"4" description:"Number of work periods before a long rest, 0 disables long rests"`
+
+	SnoozeDuration time.Duration `long:"snooze-duration" default:"5m" description:"Extra work time added by snooze"`
+	MaxSnooze      int           `long:"max-snooze" default:"3" description:"Maximum number of consecutive snoozes"`
+
+	MQTTBroker    string `long:"mqtt-broker" description:"MQTT broker (host:port) to publish status to"`
+	MQTTTopic     string `long:"mqtt-topic" default:"pomodoro/status" description:"MQTT topic for status messages"`
+	MQTTEveryTick bool   `long:"mqtt-every-tick" description:"Publish status on every tick, same as --mqtt-granularity=second"`
+
+	MQTTTransitionTopic string `long:"mqtt-transition-topic" default:"pomodoro/transition" description:"MQTT topic receiving the status on every period transition, empty disables it"`
+	MQTTGranularity     string `long:"mqtt-granularity" default:"transition" choice:"second" choice:"minute" choice:"transition" description:"How often the retained status topic is updated"`
+
+	Webhooks []string `long:"webhook" description:"URL receiving a JSON POST on every period transition, can be given more than once"`
+
+	SlackToken  string `long:"slack-token" env:"POMODORO_SLACK_TOKEN" description:"Slack user token; sets the Slack status and snoozes notifications during work periods"`
+	SlackStatus string `long:"slack-status" default:"Focusing" description:"Slack status text during work periods"`
+	SlackEmoji  string `long:"slack-emoji" default:":tomato:" description:"Slack status emoji during work periods"`
+
+	BusyCheckCmd      string        `long:"busy-check-cmd" description:"Command run periodically, the timer pauses while it exits non-zero or prints busy"`
+	BusyCheckInterval time.Duration `long:"busy-check-interval" default:"1m" description:"How often the busy check command runs"`
+
+	IdleThreshold time.Duration `long:"idle-threshold" description:"Pause work periods after being idle this long and resume on activity (default: disabled)"`
+	IdleCmd       string        `long:"idle-cmd" default:"xprintidle" description:"Command printing the idle time in milliseconds, polled when --idle-threshold is set; on Wayland have swayidle run the idle and active commands instead"`
+
+	StrictBreaks bool   `long:"strict-breaks" description:"Refuse to skip, pause or switch away from a break before it ends"`
+	BreakLockCmd string `long:"break-lock-cmd" description:"Command run for as long as a break lasts, like a screen locker, started again if it exits early"`
+
+	StatusFile   string `long:"status-file" description:"File the daemon keeps updated with the current status"`
+	StatusFormat string `long:"status-format" default:"text" choice:"text" choice:"json" description:"Format of the status file"`
+
+	HistoryFile string `long:"history-file" description:"JSON lines file every finished period is appended to, none disables it (default: $XDG_DATA_HOME/pomodoro/history.jsonl)"`
+	StateFile   string `long:"state-file" description:"File the daemon saves its state to, none disables it (default: $XDG_STATE_HOME/pomodoro/state.json)"`
+	Restore     bool   `long:"restore" description:"Resume from the state file on startup, the time the daemon was down is not counted"`
+
+	LogLevel  string `long:"log-level" default:"info" choice:"debug" choice:"info" choice:"warn" choice:"error" description:"Minimum level of the daemon log"`
+	LogFormat string `long:"log-format" default:"text" choice:"text" choice:"json" description:"Format of the daemon log"`
+	LogFile   string `long:"log-file" description:"File the daemon log is appended to (default: stderr)"`
+
+	HooksDir string `long:"hooks-dir" description:"Directory with the on-work-start, on-rest-start and on-stop hooks (default: $XDG_CONFIG_HOME/pomodoro/hooks)"`
+	TaskCmd  string `long:"task-cmd" default:"task" description:"Taskwarrior command the daemon runs to start and stop the task of a work period"`
+
+	Granularity string `long:"granularity" default:"second" choice:"second" choice:"minute" choice:"transition" description:"How often watch and get --watch print the status"`
+	Watch       bool   `long:"watch" description:"Make get keep printing the status in place"`
+	Follow      bool   `long:"follow" description:"Make get keep printing the status, one line per update"`
+	Count       bool   `long:"count" description:"Make get print only the number of work periods completed today"`
+	All         bool   `long:"all" description:"Make get print the status of every timer"`
+	Task        string `long:"task" description:"Taskwarrior task ID or UUID start attaches the work period to"`
+	Format      string `long:"format" default:"text" choice:"text" choice:"waybar" description:"Output format of get, waybar prints JSON for a Waybar custom module"`
+	Template    string `long:"template" description:"Go template get formats the status with, e.g. '{{.Emoji}} {{.Period}} {{.Remaining}}' or '{{.Emoji}} {{.GoalProgress}}'"`
+	JSON        bool   `long:"json" description:"Make stats print JSON"`
+	Color       string `long:"color" default:"auto" choice:"auto" choice:"always" choice:"never" description:"Color the get output by period (auto: only on a terminal)"`
+
+	// The notification texts default to the ones of the language.
+	Lang string `long:"lang" description:"Language of the notifications and messages, like en or ru (default: from LC_ALL, LC_MESSAGES or LANG)"`
+
+	WorkTitle       string `long:"work-title" description:"Notification title template when work starts"`
+	WorkMessage     string `long:"work-message" description:"Notification message template when work starts, with {{.Duration}}, {{.Cycle}}, {{.Tag}}, {{.CompletedToday}} and {{.Goal}}"`
+	RestTitle       string `long:"rest-title" description:"Notification title template when rest starts"`
+	RestMessage     string `long:"rest-message" description:"Notification message template when rest starts"`
+	LongRestTitle   string `long:"long-rest-title" description:"Notification title template when a long rest starts"`
+	LongRestMessage string `long:"long-rest-message" description:"Notification message template when a long rest starts"`
+	PrepareTitle    string `long:"prepare-title" description:"Notification title template when the get-ready phase starts"`
+	PrepareMessage  string `long:"prepare-message" description:"Notification message template when the get-ready phase starts"`
+
+	WorkExpire      time.Duration `long:"work-expire" default:"5s" description:"How long the work notification stays on screen, 0 keeps it until dismissed"`
+	WorkUrgency     string        `long:"work-urgency" default:"normal" choice:"low" choice:"normal" choice:"critical" description:"Urgency of the work notification"`
+	RestExpire      time.Duration `long:"rest-expire" default:"5s" description:"How long the rest notification stays on screen, 0 keeps it until dismissed"`
+	RestUrgency     string        `long:"rest-urgency" default:"normal" choice:"low" choice:"normal" choice:"critical" description:"Urgency of the rest notification"`
+	LongRestExpire  time.Duration `long:"long-rest-expire" default:"5s" description:"How long the long rest notification stays on screen, 0 keeps it until dismissed"`
+	LongRestUrgency string        `long:"long-rest-urgency" default:"normal" choice:"low" choice:"normal" choice:"critical" description:"Urgency of the long rest notification"`
+	PrepareExpire   time.Duration `long:"prepare-expire" default:"5s" description:"How long the get-ready notification stays on screen, 0 keeps it until dismissed"`
+	PrepareUrgency  string        `long:"prepare-urgency" default:"normal" choice:"low" choice:"normal" choice:"critical" description:"Urgency of the get-ready notification"`
+
+	WorkWarnings   []time.Duration `long:"work-warning" description:"Send a heads-up notification this long before a work period ends, can be given more than once"`
+	RestWarnings   []time.Duration `long:"rest-warning" description:"Send a heads-up notification this long before a rest period ends, can be given more than once"`
+	WarningTitle   string          `long:"warning-title" description:"Notification title template of the heads-up before a period ends"`
+	WarningMessage string          `long:"warning-message" description:"Notification message template of the heads-up before a period ends, {{.Remaining}} is the time left"`
+
+	// fromFile holds the long names of the options set by the config file.
+	fromFile map[string]bool
+}
+
+// Catalog returns the messages of the configured language, or of the
+// environment's when none is set.
+func (opts *Options) Catalog() (*i18n.Catalog, error) {
+	if opts.Lang == "" {
+		return i18n.Detect(), nil
+	}
+
+	return i18n.Load(opts.Lang)
+}
+
+func (opts *Options) SetDefaultSocketPathIfNotProvided() {
+	if opts.SocketPath != "" {
+		return
+	}
+
+	if runtime.GOOS == "windows" {
+		opts.SocketPath = fmt.Sprintf(`\\.\pipe\pomodoro_%s`, os.Getenv("USERNAME"))
+		return
+	}
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = "/run"
+	}
+
+	opts.SocketPath = path.Join(runtimeDir, fmt.Sprintf("pomodoro_%s.sock", sessionKey()))
+}
+
+// sessionKey identifies the graphical session, or the login session when
+// there is no display, so independent sessions get independent sockets.
+func sessionKey() string {
+	if display := os.Getenv("DISPLAY"); display != "" {
+		return display
+	}
+
+	if display := os.Getenv("WAYLAND_DISPLAY"); display != "" {
+		return display
+	}
+
+	if session := os.Getenv("XDG_SESSION_ID"); session != "" {
+		return "session" + session
+	}
+
+	return fmt.Sprintf("uid%d", os.Getuid())
+}
+
+// SetSocketPathFromArgs uses the optional positional socket argument of the
+// daemon, get and toggle commands. The flag and SOCKET_PATH take precedence,
+// the config file does not.
+func (opts *Options) SetSocketPathFromArgs(command string, args []string) {
+	if opts.SocketPath != "" && !opts.fromFile["socket-path"] || len(args) == 0 {
+		return
+	}
+
+	// daemon stop and daemon reload talk to a running daemon, the socket
+	// comes after the subcommand.
+	if command == "daemon" && (args[0] == "stop" || args[0] == "reload") {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return
+	}
+
+	switch command {
+	case "daemon", "get", "toggle":
+		opts.SocketPath = args[0]
+	}
+}
+
+const (
+	MinPeriodDuration = 1 * time.Second
+	MinTickInterval   = 10 * time.Millisecond
+)
+
+func (opts *Options) WorkDuration() time.Duration {
+	return time.Duration(opts.WorkMinutes) * time.Minute
+}
+
+func (opts *Options) RestDuration() time.Duration {
+	return time.Duration(opts.RestMinutes) * time.Minute
+}
+
+func (opts *Options) LongRestDuration() time.Duration {
+	return time.Duration(opts.LongRestMinutes) * time.Minute
+}
+
+// HistoryFilePath resolves --history-file, returning an empty path when the
+// history file is disabled.
+func (opts *Options) HistoryFilePath() string {
+	return resolvePath(opts.HistoryFile, DefaultHistoryFile)
+}
+
+// StateFilePath resolves --state-file like HistoryFilePath.
+func (opts *Options) StateFilePath() string {
+	return resolvePath(opts.StateFile, DefaultStateFile)
+}
+
+func resolvePath(path string, defaultPath func() string) string {
+	switch path {
+	case "":
+		return defaultPath()
+	case "none":
+		return ""
+	default:
+		return path
+	}
+}
+
+// Logger creates the daemon log. The log file is kept open for the
+// lifetime of the process.
+func (opts *Options) Logger() (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.LogLevel)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", opts.LogLevel, err)
+	}
+
+	if opts.Quiet {
+		level = max(level, slog.LevelWarn)
+	}
+
+	output := io.Writer(os.Stderr)
+
+	if opts.LogFile != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.LogFile), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+
+		file, err := os.OpenFile(opts.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+
+		output = file
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+
+	if opts.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(output, handlerOptions)), nil
+	}
+
+	return slog.New(slog.NewTextHandler(output, handlerOptions)), nil
+}
+
+func (opts *Options) Validate() error {
+	if opts.WorkDuration() < MinPeriodDuration {
+		return fmt.Errorf("work period must be at least %s, got %d minutes", MinPeriodDuration, opts.WorkMinutes)
+	}
+
+	if opts.RestDuration() < MinPeriodDuration {
+		return fmt.Errorf("rest period must be at least %s, got %d minutes", MinPeriodDuration, opts.RestMinutes)
+	}
+
+	if opts.Tick < MinTickInterval {
+		return fmt.Errorf("tick must be at least %s, got %s", MinTickInterval, opts.Tick)
+	}
+
+	for _, warning := range slices.Concat(opts.WorkWarnings, opts.RestWarnings) {
+		if warning <= 0 {
+			return fmt.Errorf("warnings must be positive, got %s", warning)
+		}
+	}
+
+	for _, expire := range []time.Duration{opts.WorkExpire, opts.RestExpire, opts.LongRestExpire, opts.PrepareExpire} {
+		if expire < 0 {
+			return fmt.Errorf("notification expire time must not be negative, got %s", expire)
+		}
+	}
+
+	if opts.IdleThreshold < 0 {
+		return fmt.Errorf("idle threshold must not be negative, got %s", opts.IdleThreshold)
+	}
+
+	if opts.WaitReminder < 0 {
+		return fmt.Errorf("wait reminder must not be negative, got %s", opts.WaitReminder)
+	}
+
+	if opts.BusyCheckCmd != "" && opts.BusyCheckInterval < MinPeriodDuration {
+		return fmt.Errorf("busy check interval must be at least %s, got %s", MinPeriodDuration, opts.BusyCheckInterval)
+	}
+
+	if opts.Listen != "" && !strings.HasPrefix(opts.Listen, "tcp://") {
+		return fmt.Errorf("listen address must be tcp://HOST:PORT, got %q", opts.Listen)
+	}
+
+	if opts.Listen != "" && opts.AuthToken == "" {
+		return errors.New("--listen requires --auth-token")
+	}
+
+	if opts.HTTP != "" {
+		host, _, err := net.SplitHostPort(opts.HTTP)
+		if err != nil {
+			return fmt.Errorf("invalid http address %q: %w", opts.HTTP, err)
+		}
+
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) && opts.AuthToken == "" {
+			return errors.New("--http on a non-loopback address requires --auth-token")
+		}
+	}
+
+	if opts.Metrics != "" {
+		if _, _, err := net.SplitHostPort(opts.Metrics); err != nil {
+			return fmt.Errorf("invalid metrics address %q: %w", opts.Metrics, err)
+		}
+	}
+
+	for _, webhook := range opts.Webhooks {
+		if u, err := url.Parse(webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q", webhook)
+		}
+	}
+
+	if _, err := opts.TimerDefinitions(); err != nil {
+		return err
+	}
+
+	if opts.MaxConns < 1 {
+		return fmt.Errorf("max connections must be at least 1, got %d", opts.MaxConns)
+	}
+
+	if opts.ConnTimeout <= 0 {
+		return fmt.Errorf("connection timeout must be positive, got %s", opts.ConnTimeout)
+	}
+
+	if opts.WorkStep <= 0 {
+		return fmt.Errorf("work step must be positive, got %s", opts.WorkStep)
+	}
+
+	if opts.Prepare < 0 {
+		return fmt.Errorf("prepare must not be negative, got %s", opts.Prepare)
+	}
+
+	if opts.LongBreakInterval < 0 {
+		return fmt.Errorf("long break interval must not be negative, got %d", opts.LongBreakInterval)
+	}
+
+	if opts.LongBreakInterval > 0 && opts.LongRestDuration() < MinPeriodDuration {
+		return fmt.Errorf("long rest must be at least %s, got %s", MinPeriodDuration, opts.LongRestDuration())
+	}
+
+	if opts.SnoozeDuration < MinPeriodDuration {
+		return fmt.Errorf("snooze duration must be at least %s, got %s", MinPeriodDuration, opts.SnoozeDuration)
+	}
+
+	if opts.MaxSnooze < 0 {
+		return fmt.Errorf("max snooze must not be negative, got %d", opts.MaxSnooze)
+	}
+
+	if opts.Goal < 0 {
+		return fmt.Errorf("goal must not be negative, got %d", opts.Goal)
+	}
+
+	switch strings.ToLower(opts.StartPeriod) {
+	case "", "work", "rest":
+	default:
+		return fmt.Errorf("start period must be work or rest, got %q", opts.StartPeriod)
+	}
+
+	if opts.StartRemaining < 0 || (opts.StartRemaining > 0 && opts.StartPeriod == "") {
+		return fmt.Errorf("start remaining must be positive and used together with --start-period, got %s", opts.StartRemaining)
+	}
+
+	return nil
+}
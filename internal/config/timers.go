@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimerDefinition is a named timer given by --define-timer.
+type TimerDefinition struct {
+	Name     string
+	Work     time.Duration
+	Rest     time.Duration
+	LongRest time.Duration
+}
+
+// TimerDefinitions parses the --define-timer values, NAME=WORK/REST with
+// an optional /LONG-REST. Timers without a long rest use --long-rest.
+func (opts *Options) TimerDefinitions() ([]TimerDefinition, error) {
+	definitions := make([]TimerDefinition, 0, len(opts.Timers))
+	names := make(map[string]bool)
+
+	for _, value := range opts.Timers {
+		name, spec, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid timer %q, expected NAME=WORK/REST[/LONG-REST]", value)
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("timer %q is defined twice", name)
+		}
+
+		names[name] = true
+
+		durations := strings.Split(spec, "/")
+		if len(durations) < 2 || len(durations) > 3 {
+			return nil, fmt.Errorf("invalid timer %q, expected NAME=WORK/REST[/LONG-REST]", value)
+		}
+
+		if len(durations) == 2 {
+			durations = append(durations, opts.LongRestDuration().String())
+		}
+
+		parsed := make([]time.Duration, len(durations))
+
+		for i, duration := range durations {
+			d, err := time.ParseDuration(duration)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timer %q: %w", value, err)
+			}
+
+			if d < MinPeriodDuration {
+				return nil, fmt.Errorf("invalid timer %q: periods must be at least %s", value, MinPeriodDuration)
+			}
+
+			parsed[i] = d
+		}
+
+		definitions = append(definitions, TimerDefinition{
+			Name:     name,
+			Work:     parsed[0],
+			Rest:     parsed[1],
+			LongRest: parsed[2],
+		})
+	}
+
+	return definitions, nil
+}
@@ -0,0 +1,172 @@
+// Package client talks to a running pomodoro daemon.
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// DaemonError is an error reported by the daemon in its response.
+type DaemonError struct {
+	Message string
+}
+
+func (e *DaemonError) Error() string {
+	return "daemon error: " + e.Message
+}
+
+// DefaultTimeout bounds an exchange with the daemon when Client.Timeout is
+// zero.
+const DefaultTimeout = 10 * time.Second
+
+// Client sends requests to the daemon listening on Address.
+type Client struct {
+	Address string
+	Token   string
+	Timeout time.Duration
+
+	// Timer selects a named timer of the daemon, the main one when empty.
+	Timer string
+
+	// Transport dials Address; protocol.AddressTransport is used when nil.
+	Transport protocol.Transport
+}
+
+func New(address string) *Client {
+	return &Client{Address: address}
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+
+	return c.Timeout
+}
+
+func (c *Client) dial() (net.Conn, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = protocol.AddressTransport{}
+	}
+
+	conn, err := transport.Dial(c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to daemon: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (c *Client) send(conn io.Writer, request protocol.Request) error {
+	request.Token = c.Token
+	request.Timer = c.Timer
+	request.Version = protocol.Version
+
+	if err := protocol.WriteMessage(conn, request); err != nil {
+		return fmt.Errorf("error sending command: %w", err)
+	}
+
+	return nil
+}
+
+// Exchange sends a single request and decodes the reply into v.
+func (c *Client) Exchange(request protocol.Request, v any) error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(c.timeout()))
+
+	if err := c.send(conn, request); err != nil {
+		return err
+	}
+
+	if err := protocol.ReadMessage(bufio.NewReader(conn), v); err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	return nil
+}
+
+// Do sends a request and returns the response, or a *DaemonError if the
+// daemon rejected it.
+func (c *Client) Do(request protocol.Request) (*protocol.Response, error) {
+	var response protocol.Response
+	if err := c.Exchange(request, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, &DaemonError{Message: response.Error}
+	}
+
+	return &response, nil
+}
+
+func (c *Client) Command(command string) (*protocol.Response, error) {
+	return c.Do(protocol.Request{Cmd: command})
+}
+
+func (c *Client) status(command string) (*protocol.Status, error) {
+	response, err := c.Command(command)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Status, nil
+}
+
+func (c *Client) Status() (*protocol.Status, error) { return c.status("get") }
+func (c *Client) Toggle() (*protocol.Status, error) { return c.status("switch") }
+func (c *Client) Pause() (*protocol.Status, error)  { return c.status("pause") }
+func (c *Client) Resume() (*protocol.Status, error) { return c.status("resume") }
+func (c *Client) Skip() (*protocol.Status, error)   { return c.status("skip") }
+
+// Subscribe calls handle with every status update pushed by the daemon
+// until it closes the connection. Granularity is one of the
+// protocol.Granularity values.
+func (c *Client) Subscribe(granularity string, handle func(*protocol.Status)) error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	request := protocol.Request{
+		Cmd:  "subscribe",
+		Args: map[string]string{"granularity": granularity},
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(c.timeout()))
+
+	if err := c.send(conn, request); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		var response protocol.Response
+		if err := protocol.ReadMessage(reader, &response); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("error reading response: %w", err)
+		}
+
+		if response.Error != "" {
+			return &DaemonError{Message: response.Error}
+		}
+
+		handle(response.Status)
+	}
+}
@@ -0,0 +1,18 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// remindWaiting repeats the notification of the next period while the
+// timer waits for it to be acknowledged.
+func (p *Daemon) remindWaiting(now time.Time) {
+	if p.currentPeriod != protocol.Waiting || p.waitReminder <= 0 || now.Sub(p.remindedAt).Round(p.tick) < p.waitReminder {
+		return
+	}
+
+	p.remindedAt = now
+	p.notifyPeriod(p.nextPeriod)
+}
@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thek4n/pomodoro/internal/config"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// Bounds of the work duration when it is adjusted at runtime.
+const (
+	minAdjustedWorkDuration = time.Minute
+	maxAdjustedWorkDuration = 4 * time.Hour
+)
+
+// adjustWorkDuration changes the work duration by delta. A running work
+// period gets the same delta, keeping at least one tick of it left.
+func (p *Daemon) adjustWorkDuration(delta time.Duration) (protocol.Status, protocol.Config) {
+	current := p.initialPeriodDurations[protocol.Work]
+	adjusted := min(max(current+delta, minAdjustedWorkDuration), maxAdjustedWorkDuration)
+	delta = adjusted - current
+
+	p.initialPeriodDurations[protocol.Work] = adjusted
+
+	if p.currentPeriod == protocol.Work && delta != 0 {
+		remaining := max(p.remaining()+delta, p.tick)
+		p.setRemaining(remaining)
+		p.currentPeriodDuration = max(p.currentPeriodDuration+delta, remaining)
+		p.onStateChange()
+	}
+
+	return p.status(), p.config()
+}
+
+// setPeriodDuration changes the duration future periods of that kind start
+// with, the running period keeps its time.
+func (p *Daemon) setPeriodDuration(period protocol.Period, duration time.Duration) (protocol.Config, error) {
+	switch period {
+	case protocol.Work, protocol.Rest, protocol.LongRest:
+	default:
+		return protocol.Config{}, errors.New("period must be work, rest or long-rest")
+	}
+
+	if duration < config.MinPeriodDuration {
+		return protocol.Config{}, fmt.Errorf("duration must be at least %s", config.MinPeriodDuration)
+	}
+
+	p.initialPeriodDurations[period] = duration
+
+	return p.config(), nil
+}
+
+func (p *Daemon) handleSetRequest(request protocol.Request) (protocol.Config, error) {
+	period, err := protocol.ParsePeriod(request.Args["period"])
+	if err != nil {
+		return protocol.Config{}, err
+	}
+
+	duration, err := time.ParseDuration(request.Args["duration"])
+	if err != nil {
+		return protocol.Config{}, fmt.Errorf("invalid duration: %w", err)
+	}
+
+	return p.setPeriodDuration(period, duration)
+}
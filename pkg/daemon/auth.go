@@ -0,0 +1,17 @@
+package daemon
+
+import (
+	"crypto/subtle"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// authorized reports whether the request carries the daemon's token. Every
+// request is accepted when the daemon has no token configured.
+func (p *Daemon) authorized(request protocol.Request) bool {
+	if p.authToken == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(request.Token), []byte(p.authToken)) == 1
+}
@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// breakLockRestartDelay is how long a break lock that exited before the
+// break ended waits to be started again.
+const breakLockRestartDelay = time.Second
+
+// strictBreakCommands are the commands that would end or shorten a break,
+// which strict breaks reject.
+var strictBreakCommands = map[string]bool{
+	"switch":  true,
+	"skip":    true,
+	"pause":   true,
+	"snooze":  true,
+	"start":   true,
+	"restart": true,
+	"timer":   true,
+}
+
+// checkStrictBreak rejects the commands that would dismiss an enforced
+// break.
+func (p *Daemon) checkStrictBreak(command string) error {
+	if !p.strictBreaks || !isBreak(p.currentPeriod) || !strictBreakCommands[command] {
+		return nil
+	}
+
+	return errors.New("breaks are enforced, wait for the break to end")
+}
+
+// updateBreakLock runs the break lock command for as long as a break
+// lasts.
+func (p *Daemon) updateBreakLock() {
+	if p.breakLockCmd == "" {
+		return
+	}
+
+	locked := isBreak(p.currentPeriod)
+
+	switch {
+	case locked && p.stopBreakLock == nil:
+		ctx, cancel := context.WithCancel(context.Background())
+		p.stopBreakLock = cancel
+
+		go p.runBreakLock(ctx, p.breakLockCmd, p.clock.Now().Add(p.remaining()))
+	case !locked && p.stopBreakLock != nil:
+		p.stopBreakLock()
+		p.stopBreakLock = nil
+	}
+}
+
+// runBreakLock starts the lock command again whenever it exits before the
+// break is over, so it cannot simply be closed. It runs off the event loop
+// and gets command as a copy, a reload may change the daemon's meanwhile.
+func (p *Daemon) runBreakLock(ctx context.Context, command string, endsAt time.Time) {
+	for {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = append(os.Environ(),
+			"POMODORO_BREAK_ENDS_AT="+strconv.FormatInt(endsAt.Unix(), 10),
+			"POMODORO_REMAINING="+strconv.Itoa(int(endsAt.Sub(p.clock.Now()).Seconds())),
+		)
+		killProcessGroup(cmd)
+
+		output, err := cmd.CombinedOutput()
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.logger.Warn("break lock exited before the break ended, restarting it",
+			"cmd", command,
+			"error", err,
+			"output", string(output),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(breakLockRestartDelay):
+		}
+	}
+}
+
+func (p *Daemon) endBreakLock() {
+	if p.stopBreakLock != nil {
+		p.stopBreakLock()
+		p.stopBreakLock = nil
+	}
+}
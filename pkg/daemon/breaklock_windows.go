@@ -0,0 +1,9 @@
+package daemon
+
+import (
+	"os/exec"
+)
+
+// killProcessGroup leaves the default of killing the command, Windows has
+// no process groups to signal.
+func killProcessGroup(cmd *exec.Cmd) {}
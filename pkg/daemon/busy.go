@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const busyCheckTimeout = 10 * time.Second
+
+// checkBusy runs the busy check command and pauses the timer when it
+// reports busy, resuming it once it reports free again. Only changes of
+// the reported state act on the timer, so a manual resume during a busy
+// stretch is kept.
+func (p *Daemon) checkBusy(ctx context.Context) {
+	if !p.busyChecking.CompareAndSwap(false, true) {
+		return
+	}
+	defer p.busyChecking.Store(false)
+
+	busy, err := p.runBusyCheck(ctx)
+	if err != nil {
+		p.logger.Warn("busy check failed", "cmd", p.busyCheckCmd, "error", err)
+		return
+	}
+
+	p.do(func() { p.applyBusy(busy) })
+}
+
+func (p *Daemon) applyBusy(busy bool) {
+	if busy == p.lastBusy {
+		return
+	}
+
+	p.lastBusy = busy
+
+	switch {
+	case busy && p.isTicking():
+		p.logger.Info("busy, pausing the timer")
+		p.setPaused(true)
+		p.busyPaused = true
+		p.onStateChange()
+	case !busy && p.busyPaused:
+		p.busyPaused = false
+
+		if p.paused {
+			p.logger.Info("free again, resuming the timer")
+			p.setPaused(false)
+			p.onStateChange()
+		}
+	}
+}
+
+// runBusyCheck treats a non-zero exit status or "busy" on stdout as busy.
+func (p *Daemon) runBusyCheck(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, busyCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", p.busyCheckCmd).Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(string(output)) == "busy", nil
+}
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"time"
+)
+
+// Clock is the source of time for the timer, so it can be driven by a fake
+// clock instead of real sleeps.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// sleepClock is a Clock that knows how long the machine slept between two
+// of its times. The system clock does not, a suspend shows there as the
+// wall clock running ahead of the monotonic one.
+type sleepClock interface {
+	Slept(last, now time.Time) time.Duration
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,393 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// fakeClock only moves when the test advances it. Its tickers fire on the
+// way, each tick handled by the event loop before the clock moves on.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	sleeps  []fakeSleep
+}
+
+type fakeTicker struct {
+	clock    *fakeClock
+	interval time.Duration
+	next     time.Time
+	c        chan time.Time
+	stopped  bool
+}
+
+type fakeSleep struct {
+	at    time.Time
+	slept time.Duration
+}
+
+// newFakeClock starts in the morning of the current day, so the daily
+// counters do not roll over during a test.
+func newFakeClock() *fakeClock {
+	year, month, day := time.Now().Date()
+
+	return &fakeClock{now: time.Date(year, month, day, 9, 0, 0, 0, time.Local)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ticker := &fakeTicker{clock: c, interval: d, next: c.now.Add(d), c: make(chan time.Time)}
+	c.tickers = append(c.tickers, ticker)
+
+	return ticker
+}
+
+func (c *fakeClock) Slept(last, now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var slept time.Duration
+
+	for _, sleep := range c.sleeps {
+		if sleep.at.After(last) && !sleep.at.After(now) {
+			slept += sleep.slept
+		}
+	}
+
+	return slept
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.stopped = true
+}
+
+// advance moves the clock forward by d, delivering every tick due on the
+// way and waiting for the event loop to handle it.
+func (c *fakeClock) advance(t *testing.T, p *Daemon, d time.Duration) {
+	t.Helper()
+
+	end := c.Now().Add(d)
+
+	for {
+		ticker, at := c.nextTick(end)
+		if ticker == nil {
+			break
+		}
+
+		ticker.c <- at
+
+		if err := p.do(func() {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c.mu.Lock()
+	c.now = end
+	c.mu.Unlock()
+}
+
+// nextTick moves the clock to the next tick due until end. A ticker that
+// fell behind, like after a suspend, fires right away.
+func (c *fakeClock) nextTick(end time.Time) (*fakeTicker, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var next *fakeTicker
+
+	for _, ticker := range c.tickers {
+		if !ticker.stopped && !ticker.next.After(end) && (next == nil || ticker.next.Before(next.next)) {
+			next = ticker
+		}
+	}
+
+	if next == nil {
+		return nil, time.Time{}
+	}
+
+	if next.next.After(c.now) {
+		c.now = next.next
+	}
+
+	next.next = c.now.Add(next.interval)
+
+	return next, c.now
+}
+
+// suspend makes the next tick find that the machine slept for d. Like
+// the monotonic clock the deadline is based on, the clock itself does not
+// move.
+func (c *fakeClock) suspend(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sleeps = append(c.sleeps, fakeSleep{at: c.now.Add(time.Nanosecond), slept: d})
+}
+
+func TestLongBreakCycle(t *testing.T) {
+	clock := newFakeClock()
+
+	p, c := startDaemon(t, Config{
+		Clock:             clock,
+		LongRestDuration:  2 * time.Minute,
+		LongBreakInterval: 2,
+	})
+
+	mustStatus(t)(c.Toggle())
+
+	steps := []struct {
+		after     time.Duration
+		period    protocol.Period
+		breakType string
+		untilLong int
+	}{
+		{testWorkDuration, protocol.Rest, breakTypeShort, 1},
+		{testRestDuration, protocol.Work, "", 1},
+		{testWorkDuration, protocol.LongRest, breakTypeLong, 0},
+		{2 * time.Minute, protocol.Work, "", 2},
+		{testWorkDuration, protocol.Rest, breakTypeShort, 1},
+	}
+
+	for i, step := range steps {
+		clock.advance(t, p, step.after)
+
+		status := mustStatus(t)(c.Status())
+		if status.PeriodCode != step.period || status.BreakType != step.breakType || status.SessionsUntilLongBreak != step.untilLong {
+			t.Fatalf("step %d: got %s break %q, %d until long break; want %s break %q, %d until long break",
+				i, status.Period, status.BreakType, status.SessionsUntilLongBreak,
+				step.period, step.breakType, step.untilLong)
+		}
+	}
+
+	if status := mustStatus(t)(c.Status()); status.CompletedToday != 3 {
+		t.Errorf("CompletedToday = %d, want 3", status.CompletedToday)
+	}
+}
+
+func TestPauseFreezesRemaining(t *testing.T) {
+	clock := newFakeClock()
+	p, c := startDaemon(t, Config{Clock: clock})
+
+	mustStatus(t)(c.Toggle())
+	clock.advance(t, p, 30*time.Second)
+
+	status := mustStatus(t)(c.Pause())
+	if want := testWorkDuration - 30*time.Second; status.RestOfTime != want {
+		t.Fatalf("remaining after pause = %s, want %s", status.RestOfTime, want)
+	}
+
+	clock.advance(t, p, time.Hour)
+
+	status = mustStatus(t)(c.Status())
+	if !status.Paused || status.PausedPeriod != "Work" || status.RestOfTime != testWorkDuration-30*time.Second {
+		t.Fatalf("after an hour paused: paused %t in %q with %s left", status.Paused, status.PausedPeriod, status.RestOfTime)
+	}
+
+	mustStatus(t)(c.Resume())
+	clock.advance(t, p, testWorkDuration-30*time.Second)
+
+	if status := mustStatus(t)(c.Status()); status.PeriodCode != protocol.Rest {
+		t.Fatalf("after the rest of the work period: %s, want Rest", status.Period)
+	}
+}
+
+func TestCheckSuspend(t *testing.T) {
+	tests := []struct {
+		name          string
+		onSuspend     string
+		slept         time.Duration
+		wantPaused    bool
+		wantRemaining time.Duration
+	}{
+		{"count", onSuspendCount, time.Minute, false, testWorkDuration - 2*time.Second - time.Minute},
+		{"pause", onSuspendPause, time.Minute, true, testWorkDuration - 2*time.Second},
+		{"short", onSuspendCount, suspendThreshold / 2, false, testWorkDuration - 2*time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			p, c := startDaemon(t, Config{Clock: clock, OnSuspend: tt.onSuspend, HistorySize: 10})
+
+			mustStatus(t)(c.Toggle())
+			clock.advance(t, p, time.Second)
+			clock.suspend(tt.slept)
+			clock.advance(t, p, time.Second)
+
+			status := mustStatus(t)(c.Status())
+			if status.Paused != tt.wantPaused || status.RestOfTime != tt.wantRemaining {
+				t.Fatalf("after suspend: paused %t with %s left, want paused %t with %s left",
+					status.Paused, status.RestOfTime, tt.wantPaused, tt.wantRemaining)
+			}
+
+			mustStatus(t)(c.Toggle())
+
+			response, err := c.Command("history")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wantSuspended time.Duration
+			if tt.slept >= suspendThreshold {
+				wantSuspended = tt.slept
+			}
+
+			if len(response.History) != 1 || response.History[0].Suspended != wantSuspended {
+				t.Fatalf("history = %+v, want one entry suspended for %s", response.History, wantSuspended)
+			}
+		})
+	}
+}
+
+func TestSkipWorkIsNotCounted(t *testing.T) {
+	clock := newFakeClock()
+
+	p, c := startDaemon(t, Config{
+		Clock:             clock,
+		LongRestDuration:  2 * time.Minute,
+		LongBreakInterval: 2,
+	})
+
+	mustStatus(t)(c.Toggle())
+
+	for i := range 2 {
+		status := mustStatus(t)(c.Skip())
+		if status.PeriodCode != protocol.Rest || status.CompletedToday != 0 || status.SessionsUntilLongBreak != 2 {
+			t.Fatalf("skip %d = %s, %d completed, %d until long break; want Rest, 0 completed, 2 until long break",
+				i, status.Period, status.CompletedToday, status.SessionsUntilLongBreak)
+		}
+
+		mustStatus(t)(c.Skip())
+	}
+
+	clock.advance(t, p, testWorkDuration)
+
+	if status := mustStatus(t)(c.Status()); status.PeriodCode != protocol.Rest || status.CompletedToday != 1 {
+		t.Fatalf("after a full work period = %s with %d completed, want Rest with 1", status.Period, status.CompletedToday)
+	}
+}
+
+func TestSkipOneShotWorkIsNotCounted(t *testing.T) {
+	clock := newFakeClock()
+
+	p, c := startDaemon(t, Config{
+		Clock:             clock,
+		LongRestDuration:  2 * time.Minute,
+		LongBreakInterval: 2,
+	})
+
+	timer := func() {
+		t.Helper()
+
+		response, err := c.Do(protocol.Request{Cmd: "timer", Args: map[string]string{"duration": "25m"}})
+		if err != nil || response.Error != "" {
+			t.Fatalf("timer: %v %s", err, response.Error)
+		}
+	}
+
+	timer()
+
+	status := mustStatus(t)(c.Skip())
+	if status.PeriodCode != protocol.Stopped || status.CompletedToday != 0 || status.SessionsUntilLongBreak != 2 {
+		t.Fatalf("skip = %s, %d completed, %d until long break; want Stopped, 0 completed, 2 until long break",
+			status.Period, status.CompletedToday, status.SessionsUntilLongBreak)
+	}
+
+	timer()
+	clock.advance(t, p, 25*time.Minute)
+
+	if status := mustStatus(t)(c.Status()); status.PeriodCode != protocol.Stopped || status.CompletedToday != 1 {
+		t.Fatalf("after a full timer = %s with %d completed, want Stopped with 1", status.Period, status.CompletedToday)
+	}
+}
+
+func TestSnoozeCountsWorkOnce(t *testing.T) {
+	clock := newFakeClock()
+	historyFile := filepath.Join(t.TempDir(), "history.jsonl")
+
+	p, c := startDaemon(t, Config{
+		Clock:          clock,
+		HistorySize:    10,
+		HistoryFile:    historyFile,
+		SnoozeDuration: 5 * time.Minute,
+		MaxSnooze:      1,
+	})
+
+	mustStatus(t)(c.Toggle())
+	clock.advance(t, p, testWorkDuration)
+
+	if response, err := c.Command("snooze"); err != nil || response.Error != "" {
+		t.Fatalf("snooze: %v %+v", err, response)
+	}
+
+	clock.advance(t, p, 5*time.Minute)
+
+	status := mustStatus(t)(c.Status())
+	if status.PeriodCode != protocol.Rest || status.CompletedToday != 1 {
+		t.Fatalf("after the snoozed work = %s with %d completed, want Rest with 1", status.Period, status.CompletedToday)
+	}
+
+	completed := func(entries []protocol.HistoryEntry) int {
+		n := 0
+
+		for _, entry := range entries {
+			if entry.Period == protocol.Work.String() && !entry.Skipped {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	response, err := c.Command("history")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := completed(response.History); n != 1 {
+		t.Fatalf("history has %d completed work periods, want 1: %+v", n, response.History)
+	}
+
+	data, err := os.ReadFile(historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []protocol.HistoryEntry
+
+	for line := range strings.Lines(string(data)) {
+		var entry protocol.HistoryEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("history file line %q: %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if n := completed(entries); n != 1 || len(entries) != len(response.History) {
+		t.Fatalf("history file has %d completed work periods in %d entries, want 1 in %d", n, len(entries), len(response.History))
+	}
+}
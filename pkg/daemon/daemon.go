@@ -0,0 +1,1407 @@
+// Package daemon runs the pomodoro timer and serves it over a socket.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/i18n"
+	"github.com/thek4n/pomodoro/pkg/notify"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// What the daemon does when a rest period ends.
+const (
+	afterRestWork   = "work"
+	afterRestStop   = "stop"
+	afterRestPrompt = "prompt"
+)
+
+// What the daemon does when a work period ends.
+const (
+	afterWorkRest   = "rest"
+	afterWorkPrompt = "prompt"
+)
+
+type Config struct {
+	SocketPath   string
+	AuthToken    string
+	WorkDuration time.Duration
+	RestDuration time.Duration
+	WorkStep     time.Duration
+	Prepare      time.Duration
+	Verbose      bool
+	NotifyStart  bool
+	Notifier     notify.Notifier
+	Tick         time.Duration
+	WorkSound    string
+	RestSound    string
+	SoundPlayer  string
+	HistorySize  int
+	MaxConns     int
+	ConnTimeout  time.Duration
+	Goal         int
+	ManualSwitch bool
+	AfterRest    string
+	AfterWork    string
+	OnSuspend    string
+	OneShot      bool
+	RestOnly     bool
+	Logger       *slog.Logger
+
+	// Clock drives the timer, the system clock when nil.
+	Clock Clock
+
+	// WaitReminder is how often the notification is repeated while the
+	// timer waits for the next period, never when zero.
+	WaitReminder time.Duration
+
+	// LongBreakInterval enables long rests after that many work periods.
+	LongRestDuration  time.Duration
+	LongBreakInterval int
+
+	SnoozeDuration time.Duration
+	MaxSnooze      int
+
+	// Transport creates the daemon's listener, picked from the socket
+	// address when nil.
+	Transport protocol.Transport
+
+	// Listen is a tcp://host:port address accepting clients in addition to
+	// the socket, on any interface. It should only be set together with
+	// AuthToken.
+	Listen string
+
+	// HTTPAddress enables the REST API on host:port when not empty.
+	HTTPAddress string
+
+	// MetricsAddress enables the Prometheus /metrics endpoint on host:port
+	// when not empty.
+	MetricsAddress string
+
+	// DBus exposes the daemon on the session bus, GnomePomodoro adds
+	// gnome-pomodoro's interface for integrations written for it.
+	DBus          bool
+	GnomePomodoro bool
+
+	// MQTTBroker enables publishing status to MQTT when not empty.
+	MQTTBroker          string
+	MQTTTopic           string
+	MQTTTransitionTopic string
+
+	// MQTTGranularity is how often the status topic is updated, one of the
+	// protocol.Granularity values, on transitions only when empty.
+	MQTTGranularity string
+
+	// Webhooks receive a JSON POST on every period transition.
+	Webhooks []string
+
+	// SlackToken enables setting the Slack status and DND during work.
+	SlackToken  string
+	SlackStatus string
+	SlackEmoji  string
+
+	// BusyCheckCmd enables pausing the timer while it reports busy.
+	BusyCheckCmd      string
+	BusyCheckInterval time.Duration
+
+	// IdleThreshold enables pausing work periods once the user has been
+	// idle that long, as reported by IdleCmd or the idle command.
+	IdleThreshold time.Duration
+	IdleCmd       string
+
+	// StrictBreaks rejects the commands that would end a break early.
+	StrictBreaks bool
+
+	// BreakLockCmd is run for as long as a break lasts, and started again
+	// if it exits before the break is over.
+	BreakLockCmd string
+
+	// StatusFile enables writing the status to a file when not empty.
+	StatusFile   string
+	StatusFormat string
+
+	// HooksDir holds executables run when the daemon enters a period.
+	HooksDir string
+
+	// TaskCmd is the Taskwarrior command work periods attached to a task
+	// run, task when empty.
+	TaskCmd string
+
+	// HistoryFile enables persisting the history when not empty.
+	HistoryFile string
+
+	// StateFile enables saving the state when not empty, Restore resumes
+	// from it on startup.
+	StateFile string
+	Restore   bool
+
+	// StartPeriod is the period the daemon begins in, Stopped when zero.
+	StartPeriod    protocol.Period
+	StartRemaining time.Duration
+
+	Notifications map[protocol.Period]NotificationTemplate
+
+	// Catalog translates the daemon's own notifications and the period
+	// names in notification templates, English when nil.
+	Catalog *i18n.Catalog
+
+	// Warnings are how long before the end of a period a heads-up
+	// notification is sent, rendered from WarningNotification.
+	Warnings            map[protocol.Period][]time.Duration
+	WarningNotification NotificationTemplate
+
+	// Timers are named timers run next to the main one, each with its own
+	// durations, cycle and history.
+	Timers []TimerConfig
+
+	// Reload re-reads the configuration for the reload command and SIGHUP,
+	// which are rejected when it is nil.
+	Reload func() (Config, error)
+}
+
+// Daemon keeps the timer state in a single goroutine, the event loop run by
+// Serve. Connections and background checks hand it closures through do
+// instead of locking the state.
+type Daemon struct {
+	clock                  Clock
+	commands               chan func()
+	stop                   chan struct{}
+	stopOnce               sync.Once
+	stopped                chan struct{}
+	socketPath             string
+	listen                 string
+	httpAddress            string
+	metricsAddress         string
+	dbusEnabled            bool
+	gnomePomodoro          bool
+	dbus                   *dbusService
+	authToken              string
+	startedAt              time.Time
+	transport              protocol.Transport
+	verbose                bool
+	notifyStart            bool
+	notifier               notify.Notifier
+	tick                   time.Duration
+	workSound              string
+	restSound              string
+	soundPlayer            string
+	logger                 *slog.Logger
+	manualSwitch           bool
+	oneShot                bool
+	restOnly               bool
+	currentOneShot         bool
+	snoozeDuration         time.Duration
+	maxSnooze              int
+	snoozeCount            int
+	mutedUntil             time.Time
+	workStep               time.Duration
+	afterRest              string
+	afterWork              string
+	waitReminder           time.Duration
+	remindedAt             time.Time
+	waitingSince           time.Time
+	overtimeEntry          *protocol.HistoryEntry
+	onSuspend              string
+	lastTickAt             time.Time
+	suspendedFor           time.Duration
+	longBreakInterval      int
+	cycleSessions          int
+	busyCheckCmd           string
+	busyCheckInterval      time.Duration
+	busyChecking           atomic.Bool
+	lastBusy               bool
+	busyPaused             bool
+	idleThreshold          time.Duration
+	idleCmd                string
+	idleChecking           atomic.Bool
+	idleCheckFailing       atomic.Bool
+	idlePaused             bool
+	idlePausedIn           time.Time
+	periodIdle             time.Duration
+	strictBreaks           bool
+	breakLockCmd           string
+	stopBreakLock          context.CancelFunc
+	statusFile             string
+	statusFormat           string
+	lastStatusFile         []byte
+	hooksDir               string
+	hookPeriod             protocol.Period
+	historyFile            string
+	skipped                bool
+	workCounted            bool
+	stateFile              string
+	lastStateFile          []byte
+	restore                bool
+	connections            chan struct{}
+	connTimeout            time.Duration
+	transitionSeq          uint64
+	mqtt                   *mqttPublisher
+	webhooks               *webhookSender
+	slack                  *slackIntegration
+	taskwarrior            *taskwarrior
+	task                   string
+	taskStarted            bool
+	tag                    string
+	notifications          map[protocol.Period]NotificationTemplate
+	catalog                *i18n.Catalog
+	warnings               map[protocol.Period][]time.Duration
+	warningTemplate        NotificationTemplate
+	warnedRemaining        time.Duration
+	reloadConfig           func() (Config, error)
+	name                   string
+	timers                 map[string]*Daemon
+	currentPeriod          protocol.Period
+	nextPeriod             protocol.Period
+	currentRestOfTime      time.Duration
+	periodEndsAt           time.Time
+	currentPeriodDuration  time.Duration
+	periodStartedAt        time.Time
+	paused                 bool
+	completedWorkSessions  int
+	completedToday         int
+	focusTime              time.Duration
+	counterDay             string
+	archivedDays           []protocol.DaySummary
+	initialPeriodDurations map[protocol.Period]time.Duration
+	historySize            int
+	history                []protocol.HistoryEntry
+	lastEntry              protocol.HistoryEntry
+	goal                   int
+	startPeriod            protocol.Period
+	startRemaining         time.Duration
+	subscribers            map[*subscriber]struct{}
+}
+
+// defaultMaxConns limits concurrent connections when Config leaves
+// MaxConns unset.
+const defaultMaxConns = 128
+
+// defaultConnTimeout bounds reading a request and writing a response when
+// Config leaves ConnTimeout unset.
+const defaultConnTimeout = 10 * time.Second
+
+func New(cfg Config) *Daemon {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	notifier := cfg.Notifier
+	if notifier == nil {
+		notifier = &notify.Exec{Logger: logger}
+	}
+
+	catalog := cfg.Catalog
+	if catalog == nil {
+		catalog = i18n.English()
+	}
+
+	clock := cfg.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	transport := cfg.Transport
+	if transport == nil {
+		transport = protocol.AddressTransport{}
+	}
+
+	maxConns := cfg.MaxConns
+	if maxConns <= 0 {
+		maxConns = defaultMaxConns
+	}
+
+	connTimeout := cfg.ConnTimeout
+	if connTimeout <= 0 {
+		connTimeout = defaultConnTimeout
+	}
+
+	var mqtt *mqttPublisher
+	if cfg.MQTTBroker != "" {
+		granularity := cfg.MQTTGranularity
+		if granularity == "" {
+			granularity = protocol.GranularityTransition
+		}
+
+		mqtt = newMQTTPublisher(cfg.MQTTBroker, cfg.MQTTTopic, cfg.MQTTTransitionTopic, granularity, logger)
+	}
+
+	var webhooks *webhookSender
+	if len(cfg.Webhooks) > 0 {
+		webhooks = newWebhookSender(cfg.Webhooks, logger)
+	}
+
+	var slack *slackIntegration
+	if cfg.SlackToken != "" {
+		slack = newSlackIntegration(cfg.SlackToken, cfg.SlackStatus, cfg.SlackEmoji, logger)
+	}
+
+	taskCmd := cfg.TaskCmd
+	if taskCmd == "" {
+		taskCmd = "task"
+	}
+
+	p := &Daemon{
+		socketPath:        cfg.SocketPath,
+		listen:            cfg.Listen,
+		httpAddress:       cfg.HTTPAddress,
+		metricsAddress:    cfg.MetricsAddress,
+		dbusEnabled:       cfg.DBus,
+		gnomePomodoro:     cfg.GnomePomodoro,
+		authToken:         cfg.AuthToken,
+		transport:         transport,
+		verbose:           cfg.Verbose,
+		notifyStart:       cfg.NotifyStart,
+		notifier:          notifier,
+		tick:              cfg.Tick,
+		workStep:          cfg.WorkStep,
+		workSound:         cfg.WorkSound,
+		restSound:         cfg.RestSound,
+		soundPlayer:       cfg.SoundPlayer,
+		logger:            logger,
+		historySize:       cfg.HistorySize,
+		connections:       make(chan struct{}, maxConns),
+		connTimeout:       connTimeout,
+		goal:              cfg.Goal,
+		startPeriod:       cfg.StartPeriod,
+		startRemaining:    cfg.StartRemaining,
+		manualSwitch:      cfg.ManualSwitch,
+		afterRest:         cfg.AfterRest,
+		afterWork:         cfg.AfterWork,
+		waitReminder:      cfg.WaitReminder,
+		onSuspend:         cfg.OnSuspend,
+		longBreakInterval: cfg.LongBreakInterval,
+		busyCheckCmd:      cfg.BusyCheckCmd,
+		busyCheckInterval: cfg.BusyCheckInterval,
+		idleThreshold:     cfg.IdleThreshold,
+		idleCmd:           cfg.IdleCmd,
+		strictBreaks:      cfg.StrictBreaks,
+		breakLockCmd:      cfg.BreakLockCmd,
+		oneShot:           cfg.OneShot,
+		restOnly:          cfg.RestOnly,
+		snoozeDuration:    cfg.SnoozeDuration,
+		maxSnooze:         cfg.MaxSnooze,
+		mqtt:              mqtt,
+		webhooks:          webhooks,
+		slack:             slack,
+		taskwarrior:       newTaskwarrior(taskCmd),
+		statusFile:        cfg.StatusFile,
+		statusFormat:      cfg.StatusFormat,
+		hooksDir:          cfg.HooksDir,
+		historyFile:       cfg.HistoryFile,
+		stateFile:         cfg.StateFile,
+		restore:           cfg.Restore,
+		notifications:     cfg.Notifications,
+		catalog:           catalog,
+		warnings:          cfg.Warnings,
+		warningTemplate:   cfg.WarningNotification,
+		reloadConfig:      cfg.Reload,
+		subscribers:       make(map[*subscriber]struct{}),
+		commands:          make(chan func()),
+		stop:              make(chan struct{}),
+		stopped:           make(chan struct{}),
+		clock:             clock,
+		counterDay:        clock.Now().Local().Format(dayFormat),
+		currentPeriod:     protocol.Work,
+		currentRestOfTime: cfg.WorkDuration,
+		hookPeriod:        protocol.Stopped,
+		initialPeriodDurations: map[protocol.Period]time.Duration{
+			protocol.Work:     cfg.WorkDuration,
+			protocol.Rest:     cfg.RestDuration,
+			protocol.LongRest: cfg.LongRestDuration,
+			protocol.Prepare:  cfg.Prepare,
+		},
+	}
+
+	for _, timer := range cfg.Timers {
+		p.addTimer(cfg, timer)
+	}
+
+	return p
+}
+
+// Start listens on the socket, or takes the sockets passed by systemd
+// socket activation, and serves until Shutdown is called.
+func (p *Daemon) Start() error {
+	listeners, err := activatedListeners()
+	if err != nil {
+		return err
+	}
+
+	if len(listeners) > 0 {
+		p.logger.Info("using sockets passed by systemd", "count", len(listeners))
+	} else {
+		listener, err := p.transport.Listen(p.socketPath)
+		if err != nil {
+			return err
+		}
+		defer p.removeExistingSocket()
+
+		listeners = append(listeners, listener)
+	}
+
+	if p.listen != "" {
+		tcpListener, err := protocol.ListenTCP(p.listen)
+		if err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+
+			return err
+		}
+
+		listeners = append(listeners, tcpListener)
+	}
+
+	return p.Serve(listeners...)
+}
+
+// Serve runs the daemon on already open listeners until Shutdown is
+// called. The listeners are closed when Serve returns.
+func (p *Daemon) Serve(listeners ...net.Listener) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	closeListeners := func() {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+	}
+	defer closeListeners()
+
+	go func() {
+		<-p.stop
+		p.sdNotify("STOPPING=1")
+		cancel()
+		closeListeners()
+	}()
+
+	if p.httpAddress != "" {
+		closeHTTP, err := p.listenHTTP()
+		if err != nil {
+			return err
+		}
+		defer closeHTTP()
+	}
+
+	if p.metricsAddress != "" {
+		closeMetrics, err := p.listenMetrics()
+		if err != nil {
+			return err
+		}
+		defer closeMetrics()
+	}
+
+	if p.dbusEnabled || p.gnomePomodoro {
+		dbus, err := p.connectDBus(p.gnomePomodoro)
+		if err != nil {
+			return err
+		}
+		defer dbus.close()
+
+		p.dbus = dbus
+	}
+
+	p.startedAt = p.clock.Now()
+
+	// The integrations stop with ctx and are waited for, so Serve does not
+	// leave them behind.
+	var integrations sync.WaitGroup
+
+	if p.mqtt != nil {
+		integrations.Go(func() { p.mqtt.run(ctx) })
+	}
+
+	if p.webhooks != nil {
+		integrations.Go(func() { p.webhooks.run(ctx) })
+	}
+
+	if p.slack != nil {
+		integrations.Go(func() { p.slack.run(ctx) })
+	}
+
+	go p.runTaskCommands()
+	defer p.closeTaskCommands()
+
+	go p.run(ctx)
+
+	for _, timer := range p.timers {
+		timer.startedAt = p.startedAt
+		go timer.run(ctx)
+	}
+
+	attrs := []any{"socket", p.socketPath}
+
+	if p.listen != "" {
+		attrs = append(attrs, "listen", p.listen)
+	}
+
+	if p.httpAddress != "" {
+		attrs = append(attrs, "http", p.httpAddress)
+	}
+
+	if p.metricsAddress != "" {
+		attrs = append(attrs, "metrics", p.metricsAddress)
+	}
+
+	if len(p.timers) > 0 {
+		attrs = append(attrs, "timers", slices.Sorted(maps.Keys(p.timers)))
+	}
+
+	if p.dbus != nil {
+		attrs = append(attrs, "dbus", dbusServiceName)
+	}
+
+	if p.dbus != nil && p.dbus.gnome != nil {
+		attrs = append(attrs, "gnome", gnomeServiceName)
+	}
+
+	p.logger.Info("daemon started", attrs...)
+	p.sdNotify("READY=1")
+
+	if interval := watchdogInterval(); interval > 0 {
+		go p.pingWatchdog(interval)
+	}
+
+	if p.notifyStart {
+		go p.sendNotification(notify.Notification{
+			Title:   p.catalog.Text("notification.ready-title"),
+			Message: fmt.Sprintf(p.catalog.Text("notification.ready-message"), p.socketPath),
+		})
+	}
+
+	var wg sync.WaitGroup
+
+	for _, listener := range listeners {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			p.accept(ctx, listener)
+		}()
+	}
+
+	wg.Wait()
+	<-p.stopped
+
+	for _, timer := range p.timers {
+		<-timer.stopped
+	}
+
+	integrations.Wait()
+
+	p.logger.Info("daemon stopped")
+
+	return nil
+}
+
+// acceptRetryDelay bounds how long accept waits after a failure, like
+// running out of file descriptors, before trying again.
+const acceptRetryDelay = time.Second
+
+func (p *Daemon) accept(ctx context.Context, listener net.Listener) {
+	var delay time.Duration
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			delay = min(max(2*delay, 5*time.Millisecond), acceptRetryDelay)
+			p.logger.Warn("failed to accept connection", "address", listener.Addr().String(), "error", err, "retry_in", delay)
+			time.Sleep(delay)
+
+			continue
+		}
+
+		delay = 0
+
+		select {
+		case p.connections <- struct{}{}:
+		default:
+			p.logger.Warn("too many connections, rejecting client", "limit", cap(p.connections))
+			go p.rejectConnection(conn)
+			continue
+		}
+
+		go func() {
+			defer func() { <-p.connections }()
+			p.handleConnection(conn)
+		}()
+	}
+}
+
+func (p *Daemon) Shutdown() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// Errors of do, reported to clients as they are.
+var (
+	errStopped = errors.New("Daemon is shutting down")
+	errPanic   = errors.New("Internal daemon error")
+)
+
+// do runs f on the event loop and waits for it to finish, f may use the
+// daemon state freely. It returns errStopped without running f once the
+// loop has stopped, and errPanic when f panicked, the loop keeps going.
+func (p *Daemon) do(f func()) error {
+	var err error
+
+	done := make(chan struct{})
+
+	run := func() {
+		defer close(done)
+
+		defer func() {
+			if r := recover(); r != nil {
+				p.logger.Error("panic on the event loop", "panic", r, "stack", string(debug.Stack()))
+				err = errPanic
+			}
+		}()
+
+		f()
+	}
+
+	select {
+	case p.commands <- run:
+	case <-p.stopped:
+		return errStopped
+	}
+
+	<-done
+
+	return err
+}
+
+func (p *Daemon) restoreStartState() {
+	defer p.onStateChange()
+
+	if p.restore && p.restoreStateFile() {
+		return
+	}
+
+	if p.startPeriod != protocol.Work && p.startPeriod != protocol.Rest {
+		p.stopPeriod()
+		return
+	}
+
+	p.beginPeriod(p.startPeriod)
+	p.currentOneShot = p.oneShot || p.restOnly
+
+	if p.startRemaining > 0 {
+		p.periodStartedAt = p.periodStartedAt.Add(p.startRemaining - p.currentRestOfTime)
+		p.setRemaining(p.startRemaining)
+	}
+}
+
+func (p *Daemon) removeExistingSocket() {
+	if protocol.IsSocketFile(p.socketPath) {
+		_ = os.Remove(p.socketPath)
+	}
+}
+
+// run is the event loop, the only goroutine touching the timer state. It
+// applies commands as they arrive and counts down on every tick.
+func (p *Daemon) run(ctx context.Context) {
+	defer close(p.stopped)
+
+	p.restoreStartState()
+
+	ticker := p.clock.NewTicker(p.tick)
+	defer ticker.Stop()
+
+	var busyCheck <-chan time.Time
+
+	if p.busyCheckCmd != "" {
+		busyTicker := p.clock.NewTicker(p.busyCheckInterval)
+		defer busyTicker.Stop()
+
+		busyCheck = busyTicker.C()
+	}
+
+	var idleCheck <-chan time.Time
+
+	if p.idleThreshold > 0 && p.idleCmd != "" {
+		idleTicker := p.clock.NewTicker(idleCheckInterval)
+		defer idleTicker.Stop()
+
+		idleCheck = idleTicker.C()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// A restore resumes from the second the daemon stopped rather
+			// than from its last periodic save.
+			p.writeStateFile(true)
+			p.recordOvertimeEntry()
+			p.finishTask()
+			p.endBreakLock()
+			return
+		case command := <-p.commands:
+			command()
+		case <-busyCheck:
+			go p.checkBusy(ctx)
+		case <-idleCheck:
+			go p.checkIdle(ctx)
+		case <-ticker.C():
+			p.tickTimer()
+		}
+	}
+}
+
+func (p *Daemon) tickTimer() {
+	now := p.clock.Now()
+	p.rollOverDay(now)
+	p.checkSuspend(now)
+	p.remindWaiting(now)
+
+	if p.isTicking() && p.remaining() <= 0 {
+		p.switchTimer()
+	} else if p.isTicking() {
+		p.checkWarnings()
+		p.onTick()
+	} else if p.overtime() > 0 {
+		p.onTick()
+	}
+}
+
+func (p *Daemon) isTicking() bool {
+	return p.currentPeriod != protocol.Stopped && p.currentPeriod != protocol.Waiting && !p.paused
+}
+
+// remaining is the time left in the current period. While the timer
+// runs it is computed from the deadline, so late ticks do not make the
+// countdown drift.
+func (p *Daemon) remaining() time.Duration {
+	if !p.isTicking() {
+		return p.currentRestOfTime
+	}
+
+	return max(p.periodEndsAt.Sub(p.clock.Now()).Round(p.tick), 0)
+}
+
+// setRemaining moves the deadline so that remaining is left.
+func (p *Daemon) setRemaining(remaining time.Duration) {
+	p.currentRestOfTime = remaining
+	p.periodEndsAt = p.clock.Now().Add(remaining)
+}
+
+// setPaused freezes the remaining time when pausing and sets a new
+// deadline from it when resuming.
+func (p *Daemon) setPaused(paused bool) {
+	p.setRemaining(p.remaining())
+	p.paused = paused
+}
+
+func (p *Daemon) switchTimer() {
+	if p.currentPeriod == protocol.Prepare {
+		p.finishPreparation()
+		return
+	}
+
+	if p.currentOneShot {
+		p.finishOneShot()
+		return
+	}
+
+	switch p.currentPeriod {
+	case protocol.Work:
+		// A skipped work period is not a pomodoro, neither for the
+		// counters nor for earning a long break.
+		p.workCounted = !p.skipped
+		if p.workCounted {
+			p.countCompletedWork()
+		}
+	case protocol.Rest:
+		p.snoozeCount = 0
+	case protocol.LongRest:
+		p.snoozeCount = 0
+		p.cycleSessions = 0
+	}
+
+	previousPeriod := p.currentPeriod
+	nextPeriod := p.getReversedPeriod(p.currentPeriod)
+
+	stops := isBreak(previousPeriod) && p.afterRest == afterRestStop
+	waits := p.manualSwitch ||
+		isBreak(previousPeriod) && p.afterRest == afterRestPrompt ||
+		previousPeriod == protocol.Work && p.afterWork == afterWorkPrompt
+
+	p.setRemaining(0)
+
+	if waits && previousPeriod == protocol.Work {
+		p.holdWorkEntry()
+	} else {
+		p.recordCurrentPeriod()
+	}
+
+	switch {
+	case stops:
+		p.stopPeriod()
+	case waits:
+		p.waitForPeriod(nextPeriod)
+	default:
+		p.enterPeriod(nextPeriod)
+	}
+
+	p.printTransition(previousPeriod)
+	p.onStateChange()
+
+	announcedPeriod := nextPeriod
+	if p.currentPeriod == protocol.Prepare {
+		announcedPeriod = protocol.Prepare
+	}
+
+	p.playSound(p.soundForPeriod(announcedPeriod))
+	p.notifyPeriod(announcedPeriod)
+}
+
+func (p *Daemon) printTransition(previousPeriod protocol.Period) {
+	if !p.verbose {
+		return
+	}
+
+	fmt.Printf("%s %s -> %s (%s)\n",
+		p.clock.Now().Format("2006-01-02T15:04:05"),
+		previousPeriod.String(),
+		p.currentPeriod.String(),
+		protocol.FormatShortDuration(p.remaining()),
+	)
+}
+
+// onStateChange is called whenever the period or the pause state changes,
+// it fans the new state out to subscribers, files, hooks and MQTT.
+func (p *Daemon) onStateChange() {
+	p.transitionSeq++
+	p.warnedRemaining = p.remaining()
+
+	if p.currentPeriod != protocol.Waiting {
+		p.recordOvertimeEntry()
+	}
+
+	p.publishMQTT(true)
+	p.broadcast(true)
+	p.emitDBus()
+	p.writeStatusFile()
+	p.writeStateFile(true)
+	p.runHooks()
+	p.callWebhooks()
+	p.updateSlack()
+	p.updateTask()
+	p.updateBreakLock()
+}
+
+// onConfigChange refreshes the status consumers after a reload. It is not
+// a transition, the hooks and integrations are left alone, but subscribers
+// get the new status whatever their granularity.
+func (p *Daemon) onConfigChange() {
+	p.broadcast(true)
+	p.writeStatusFile()
+	p.writeStateFile(true)
+}
+
+// onTick is called when the running timer counts down without a
+// transition.
+func (p *Daemon) onTick() {
+	p.publishMQTT(false)
+	p.broadcast(false)
+	p.writeStatusFile()
+	p.writeStateFile(false)
+}
+
+func (p *Daemon) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("panic while handling connection", "panic", r, "stack", string(debug.Stack()))
+			p.writeResponse(conn, protocol.Response{Error: "Internal daemon error"})
+		}
+	}()
+
+	// A client that stalls before finishing its request must not hold the
+	// connection forever.
+	_ = conn.SetReadDeadline(time.Now().Add(p.connTimeout))
+
+	data, err := protocol.ReadRequest(conn)
+	if err != nil {
+		// Clients probing whether the daemon runs close without a request.
+		if !errors.Is(err, io.EOF) {
+			p.logger.Debug("failed to read request", "remote", conn.RemoteAddr().String(), "error", err)
+		}
+
+		return
+	}
+
+	var response protocol.Response
+
+	request, err := protocol.ParseRequest(data)
+
+	if err == nil {
+		p.logger.Debug("handling request", "cmd", request.Cmd, "args", request.Args, "timer", request.Timer)
+	}
+
+	timer, timerErr := p.timer(request.Timer)
+
+	switch {
+	case err != nil:
+		p.logger.Debug("invalid request", "error", err)
+		response.Error = err.Error()
+	case !p.authorized(request):
+		p.logger.Warn("rejected request with invalid auth token", "cmd", request.Cmd)
+		response.Error = "Invalid auth token"
+	case timerErr != nil:
+		response.Error = timerErr.Error()
+	case request.Cmd == "timers":
+		response.Timers = p.timerStatuses()
+	case request.Cmd == "shutdown":
+		// Over TCP the auth token, checked above, has to stand in for the
+		// socket's permissions.
+		if !protocol.IsLocal(conn) && p.authToken == "" {
+			response.Error = "shutdown is only allowed over the local socket or with an auth token"
+			break
+		}
+
+		p.writeResponse(conn, protocol.Response{Message: "Daemon is shutting down"})
+		p.Shutdown()
+
+		return
+	case request.Cmd == "reload":
+		// Loading the config runs off the event loop, only applying it
+		// needs the loop.
+		response = p.reload()
+	case request.Cmd == "watch", request.Cmd == "subscribe":
+		timer.streamStatus(conn, request)
+
+		return
+	default:
+		response = timer.runCommand(request)
+	}
+
+	p.writeResponse(conn, response)
+}
+
+func (p *Daemon) rejectConnection(conn net.Conn) {
+	defer conn.Close()
+
+	// Reading the request first lets the client finish writing it and see
+	// the error instead of a broken pipe.
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	_, _ = protocol.ReadRequest(conn)
+	p.writeResponse(conn, protocol.Response{Error: "Too many connections"})
+}
+
+// writeResponse gives up on clients that stop reading after connTimeout.
+func (p *Daemon) writeResponse(conn net.Conn, response protocol.Response) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(p.connTimeout))
+
+	err := protocol.WriteResponse(conn, response)
+	if err != nil {
+		p.logger.Debug("failed to write response", "error", err)
+	}
+
+	return err
+}
+
+// runCommand handles the request on the event loop.
+func (p *Daemon) runCommand(request protocol.Request) protocol.Response {
+	var response protocol.Response
+
+	if request.Cmd == "start" {
+		if err := p.resolveTask(&request); err != nil {
+			response.Error = err.Error()
+			return response
+		}
+	}
+
+	if err := p.do(func() { response = p.handleRequest(request) }); err != nil {
+		response = protocol.Response{Error: err.Error()}
+	}
+
+	return response
+}
+
+func (p *Daemon) handleRequest(request protocol.Request) protocol.Response {
+	var response protocol.Response
+
+	if err := p.checkStrictBreak(request.Cmd); err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	switch request.Cmd {
+	case "get":
+		status := p.status()
+		response.Status = &status
+	case "switch":
+		status := p.toggleTimer()
+		response.Status = &status
+	case "start":
+		status := p.startWork(request)
+		response.Status = &status
+	case "restart":
+		status := p.restartCycle()
+		response.Status = &status
+	case "reset":
+		status, err := p.resetPeriod()
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "timer":
+		status, err := p.handleTimerRequest(request)
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "skip":
+		status, err := p.skipTimer()
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "snooze":
+		status, err := p.snoozeTimer()
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "continue", "ack":
+		status, err := p.continueTimer()
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "pause":
+		status, err := p.pauseTimer()
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "resume":
+		status, err := p.resumeTimer()
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "mute":
+		status, err := p.handleMuteRequest(request)
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "unmute":
+		status := p.unmuteNotifications()
+		response.Status = &status
+	case "idle", "active":
+		status, err := p.handleIdleRequest(request)
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Status = &status
+	case "set":
+		config, err := p.handleSetRequest(request)
+		if err != nil {
+			response.Error = err.Error()
+			break
+		}
+
+		response.Config = &config
+	case "work-inc", "work-dec":
+		delta := p.workStep
+		if request.Cmd == "work-dec" {
+			delta = -delta
+		}
+
+		status, config := p.adjustWorkDuration(delta)
+		response.Status = &status
+		response.Config = &config
+	case "uptime":
+		uptime := p.getUptime()
+		response.Uptime = &uptime
+	case "config-get":
+		config := p.config()
+		response.Config = &config
+	case "history":
+		response.History = p.getHistory()
+		response.Days = p.getArchivedDays()
+	default:
+		response.Error = "Unknown command"
+	}
+
+	return response
+}
+
+// status builds a Status snapshot.
+func (p *Daemon) status() protocol.Status {
+	remaining := p.remaining()
+
+	status := protocol.Status{
+		Period:            p.currentPeriod.String(),
+		PeriodCode:        p.currentPeriod,
+		RestOfTime:        remaining,
+		RestOfTimeStr:     protocol.FormatDuration(remaining),
+		PeriodDuration:    p.currentPeriodDuration,
+		Paused:            p.paused,
+		CompletedSessions: p.completedWorkSessions,
+		CompletedToday:    p.completedToday,
+		Goal:              p.goal,
+		Task:              p.task,
+		Tag:               p.tag,
+		Timer:             p.name,
+		Snoozes:           p.snoozeCount,
+		TransitionSeq:     p.transitionSeq,
+		BreakType:         p.breakType(),
+
+		SessionsUntilLongBreak: p.sessionsUntilLongBreak(),
+	}
+
+	// A paused timer reports "Paused" as its period so widgets can tell it
+	// from a stopped one, the period code keeps the underlying period.
+	if p.paused {
+		status.Period = protocol.PausedPeriodName
+		status.PausedPeriod = p.currentPeriod.String()
+	}
+
+	switch p.currentPeriod {
+	case protocol.Waiting:
+		status.NextPeriod = p.nextPeriod.String()
+
+		if overtime := p.overtime(); overtime > 0 {
+			status.Overtime = overtime
+			status.RestOfTimeStr = "+" + protocol.FormatDuration(overtime)
+		}
+	case protocol.Prepare:
+		status.NextPeriod = protocol.Work.String()
+	}
+
+	if p.notificationsMuted(p.clock.Now()) {
+		mutedUntil := p.mutedUntil
+		status.MutedUntil = &mutedUntil
+	}
+
+	if status.PeriodDuration > 0 && status.RestOfTime <= status.PeriodDuration {
+		elapsed := status.PeriodDuration - status.RestOfTime
+		status.ProgressPercent = int(elapsed * 100 / status.PeriodDuration)
+	}
+
+	return status
+}
+
+func (p *Daemon) config() protocol.Config {
+	config := protocol.Config{
+		WorkDuration:      p.initialPeriodDurations[protocol.Work],
+		RestDuration:      p.initialPeriodDurations[protocol.Rest],
+		LongRestDuration:  p.initialPeriodDurations[protocol.LongRest],
+		LongBreakInterval: p.longBreakInterval,
+		Tick:              p.tick,
+		Goal:              p.goal,
+	}
+
+	describeNotifier(p.notifier, &config)
+
+	return config
+}
+
+func (p *Daemon) toggleTimer() protocol.Status {
+	switch p.currentPeriod {
+	case protocol.Stopped:
+		p.beginFirstPeriod()
+	case protocol.Waiting:
+		p.enterPeriod(p.nextPeriod)
+	default:
+		p.recordCurrentPeriod()
+		p.stopPeriod()
+	}
+
+	p.onStateChange()
+
+	return p.status()
+}
+
+// startWork begins a work period attached to the task, which is started
+// once the get-ready phase is over. The tag labels the session until the
+// timer stops.
+func (p *Daemon) startWork(request protocol.Request) protocol.Status {
+	p.recordCurrentPeriod()
+	p.enterPeriod(protocol.Work)
+	p.task = request.Args["task"]
+	p.tag = request.Args["tag"]
+	p.onStateChange()
+
+	return p.status()
+}
+
+func (p *Daemon) continueTimer() (protocol.Status, error) {
+	if p.currentPeriod != protocol.Waiting {
+		return protocol.Status{}, errors.New("timer is not waiting for confirmation")
+	}
+
+	p.enterPeriod(p.nextPeriod)
+	p.onStateChange()
+
+	return p.status(), nil
+}
+
+func (p *Daemon) beginPeriod(period protocol.Period) {
+	p.currentPeriod = period
+	p.setRemaining(p.initialPeriodDurations[period])
+	p.currentPeriodDuration = p.currentRestOfTime
+	p.periodStartedAt = p.clock.Now()
+	p.paused = false
+	p.currentOneShot = false
+}
+
+// enterPeriod begins the period, going through the get-ready phase first
+// when it is a work period and the phase is enabled.
+func (p *Daemon) enterPeriod(period protocol.Period) {
+	if period == protocol.Work && p.initialPeriodDurations[protocol.Prepare] > 0 {
+		period = protocol.Prepare
+	}
+
+	p.beginPeriod(period)
+}
+
+func (p *Daemon) finishPreparation() {
+	oneShot := p.currentOneShot
+
+	p.setRemaining(0)
+	p.recordCurrentPeriod()
+	p.beginPeriod(protocol.Work)
+	p.currentOneShot = oneShot
+
+	p.printTransition(protocol.Prepare)
+	p.onStateChange()
+	p.playSound(p.soundForPeriod(protocol.Work))
+	p.notifyPeriod(protocol.Work)
+}
+
+func (p *Daemon) waitForPeriod(period protocol.Period) {
+	p.currentPeriod = protocol.Waiting
+	p.nextPeriod = period
+	p.waitingSince = p.clock.Now()
+	p.remindedAt = p.waitingSince
+	p.setRemaining(0)
+	p.currentPeriodDuration = 0
+	p.paused = false
+}
+
+func (p *Daemon) stopPeriod() {
+	p.currentPeriod = protocol.Stopped
+	p.setRemaining(0)
+	p.currentPeriodDuration = 0
+	p.paused = false
+	p.snoozeCount = 0
+	p.tag = ""
+}
+
+func (p *Daemon) pauseTimer() (protocol.Status, error) {
+	if p.currentPeriod == protocol.Stopped {
+		return protocol.Status{}, errors.New("timer is stopped")
+	}
+
+	if p.currentPeriod == protocol.Waiting {
+		return protocol.Status{}, errors.New("timer is waiting for confirmation")
+	}
+
+	if p.paused {
+		return protocol.Status{}, errors.New("timer is already paused")
+	}
+
+	p.setPaused(true)
+	p.onStateChange()
+
+	return p.status(), nil
+}
+
+func (p *Daemon) resumeTimer() (protocol.Status, error) {
+	if !p.paused {
+		return protocol.Status{}, errors.New("timer is not paused")
+	}
+
+	p.setPaused(false)
+	p.busyPaused = false
+	p.idlePaused = false
+	p.onStateChange()
+
+	return p.status(), nil
+}
+
+func (p *Daemon) restartCycle() protocol.Status {
+	p.recordCurrentPeriod()
+	p.beginFirstPeriod()
+	p.completedWorkSessions = 0
+	p.cycleSessions = 0
+	p.snoozeCount = 0
+	p.onStateChange()
+
+	return p.status()
+}
+
+// resetPeriod starts the current period over with its full duration,
+// keeping it paused if it was.
+func (p *Daemon) resetPeriod() (protocol.Status, error) {
+	switch p.currentPeriod {
+	case protocol.Stopped:
+		return protocol.Status{}, errors.New("timer is stopped")
+	case protocol.Waiting:
+		return protocol.Status{}, errors.New("timer is waiting for confirmation")
+	}
+
+	duration := p.initialPeriodDurations[p.currentPeriod]
+	if p.currentOneShot {
+		duration = p.currentPeriodDuration
+	}
+
+	p.setRemaining(duration)
+	p.currentPeriodDuration = duration
+	p.periodStartedAt = p.clock.Now()
+	p.onStateChange()
+
+	return p.status(), nil
+}
+
+func (p *Daemon) getReversedPeriod(current protocol.Period) protocol.Period {
+	if current != protocol.Work {
+		return protocol.Work
+	}
+
+	if p.longBreakDue() {
+		return protocol.LongRest
+	}
+
+	return protocol.Rest
+}
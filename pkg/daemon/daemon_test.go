@@ -0,0 +1,506 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/client"
+	"github.com/thek4n/pomodoro/pkg/notify"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	testWorkDuration = 3 * time.Minute
+	testRestDuration = time.Minute
+)
+
+// startDaemon serves a daemon on a unix socket in a temporary directory
+// and returns a client for it. The daemon is shut down with the test.
+func startDaemon(t *testing.T, cfg Config) (*Daemon, *client.Client) {
+	t.Helper()
+
+	cfg.SocketPath = filepath.Join(t.TempDir(), "p.sock")
+
+	if cfg.WorkDuration == 0 {
+		cfg.WorkDuration = testWorkDuration
+	}
+
+	if cfg.RestDuration == 0 {
+		cfg.RestDuration = testRestDuration
+	}
+
+	if cfg.Tick == 0 {
+		cfg.Tick = time.Second
+	}
+
+	if cfg.Notifier == nil {
+		cfg.Notifier = notify.Noop{}
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.DiscardHandler)
+	}
+
+	p := New(cfg)
+
+	listener, err := p.transport.Listen(cfg.SocketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	served := make(chan error, 1)
+
+	go func() { served <- p.Serve(listener) }()
+
+	t.Cleanup(func() {
+		p.Shutdown()
+
+		if err := <-served; err != nil {
+			t.Errorf("Serve() = %v", err)
+		}
+	})
+
+	// The event loop runs once it takes a command.
+	if err := p.do(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	return p, client.New(cfg.SocketPath)
+}
+
+// rawRequest sends data as it is and returns the parsed response.
+func rawRequest(t *testing.T, c *client.Client, data string) protocol.Response {
+	t.Helper()
+
+	conn, err := net.Dial("unix", c.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(data + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var response protocol.Response
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response to %q: %v", data, err)
+	}
+
+	return response
+}
+
+// mustStatus fails the test unless a client call returned a status, like
+// mustStatus(t)(c.Toggle()).
+func mustStatus(t *testing.T) func(*protocol.Status, error) *protocol.Status {
+	return func(status *protocol.Status, err error) *protocol.Status {
+		t.Helper()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status == nil {
+			t.Fatal("response has no status")
+		}
+
+		return status
+	}
+}
+
+func TestGet(t *testing.T) {
+	_, c := startDaemon(t, Config{})
+
+	response, err := c.Command("get")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Version != protocol.Version {
+		t.Errorf("Version = %d, want %d", response.Version, protocol.Version)
+	}
+
+	status := mustStatus(t)(response.Status, nil)
+
+	if status.PeriodCode != protocol.Stopped || status.RestOfTime != 0 {
+		t.Errorf("get = %s %s, want Stopped 0s", status.Period, status.RestOfTime)
+	}
+}
+
+func TestToggle(t *testing.T) {
+	_, c := startDaemon(t, Config{})
+
+	status := mustStatus(t)(c.Toggle())
+	if status.PeriodCode != protocol.Work || status.RestOfTime != testWorkDuration {
+		t.Fatalf("first toggle = %s %s, want Work %s", status.Period, status.RestOfTime, testWorkDuration)
+	}
+
+	status = mustStatus(t)(c.Toggle())
+	if status.PeriodCode != protocol.Stopped || status.RestOfTime != 0 {
+		t.Fatalf("second toggle = %s %s, want Stopped 0s", status.Period, status.RestOfTime)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	_, c := startDaemon(t, Config{})
+
+	response, err := c.Command("switch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := mustStatus(t)(response.Status, nil)
+	if status.PeriodCode != protocol.Work {
+		t.Fatalf("switch = %s, want Work", status.Period)
+	}
+
+	status = mustStatus(t)(c.Status())
+	if status.PeriodCode != protocol.Work || status.TransitionSeq != response.Status.TransitionSeq {
+		t.Fatalf("get after switch = %s #%d, want Work #%d", status.Period, status.TransitionSeq, response.Status.TransitionSeq)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	_, c := startDaemon(t, Config{})
+
+	_, err := c.Command("bogus")
+
+	var daemonErr *client.DaemonError
+	if !errors.As(err, &daemonErr) || daemonErr.Message != "Unknown command" {
+		t.Fatalf("bogus = %v, want Unknown command", err)
+	}
+
+	mustStatus(t)(c.Status())
+}
+
+func TestMalformedRequest(t *testing.T) {
+	_, c := startDaemon(t, Config{})
+
+	response := rawRequest(t, c, `{"command":`)
+	if !strings.HasPrefix(response.Error, "malformed request") {
+		t.Fatalf("error = %q, want malformed request", response.Error)
+	}
+
+	if response.Status != nil {
+		t.Errorf("malformed request returned a status")
+	}
+
+	response = rawRequest(t, c, `{"command":"get"}`)
+	if response.Error != "" || response.Status == nil {
+		t.Fatalf("get after malformed request = %+v", response)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	clock := newFakeClock()
+	p, c := startDaemon(t, Config{Clock: clock})
+
+	mustStatus(t)(c.Toggle())
+	clock.advance(t, p, 10*time.Second)
+	mustStatus(t)(c.Pause())
+	clock.advance(t, p, time.Minute)
+
+	if _, err := c.Pause(); err == nil {
+		t.Fatal("second pause succeeded, want already paused error")
+	}
+
+	status := mustStatus(t)(c.Resume())
+	if status.Paused || status.PeriodCode != protocol.Work || status.RestOfTime != testWorkDuration-10*time.Second {
+		t.Fatalf("resume = %s paused %t with %s left, want running Work with %s left",
+			status.Period, status.Paused, status.RestOfTime, testWorkDuration-10*time.Second)
+	}
+
+	clock.advance(t, p, 20*time.Second)
+
+	if status := mustStatus(t)(c.Status()); status.RestOfTime != testWorkDuration-30*time.Second {
+		t.Fatalf("remaining after resume = %s, want %s", status.RestOfTime, testWorkDuration-30*time.Second)
+	}
+
+	if _, err := c.Resume(); err == nil {
+		t.Fatal("resume of a running timer succeeded, want an error")
+	}
+}
+
+func TestPauseThenToggle(t *testing.T) {
+	clock := newFakeClock()
+	p, c := startDaemon(t, Config{Clock: clock})
+
+	mustStatus(t)(c.Toggle())
+	clock.advance(t, p, 10*time.Second)
+	mustStatus(t)(c.Pause())
+
+	status := mustStatus(t)(c.Toggle())
+	if status.PeriodCode != protocol.Stopped || status.Paused || status.RestOfTime != 0 {
+		t.Fatalf("toggle while paused = %s paused %t with %s left, want Stopped", status.Period, status.Paused, status.RestOfTime)
+	}
+
+	status = mustStatus(t)(c.Toggle())
+	if status.PeriodCode != protocol.Work || status.Paused || status.RestOfTime != testWorkDuration {
+		t.Fatalf("toggle after stop = %s paused %t with %s left, want a fresh Work period", status.Period, status.Paused, status.RestOfTime)
+	}
+}
+
+func TestToggleTwice(t *testing.T) {
+	clock := newFakeClock()
+	p, c := startDaemon(t, Config{Clock: clock, HistorySize: 10})
+
+	mustStatus(t)(c.Toggle())
+	clock.advance(t, p, 10*time.Second)
+	mustStatus(t)(c.Toggle())
+
+	if _, err := c.Pause(); err == nil {
+		t.Fatal("pause of a stopped timer succeeded, want an error")
+	}
+
+	response, err := c.Command("history")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.History) != 1 {
+		t.Fatalf("history = %+v, want one entry", response.History)
+	}
+
+	if entry := response.History[0]; entry.Period != "Work" || !entry.Skipped || entry.Duration != 10*time.Second {
+		t.Fatalf("history entry = %+v, want a skipped Work period of 10s", entry)
+	}
+}
+
+// panicConn is a connection whose reads panic, to make a bug on the
+// goroutine handling the connection.
+type panicConn struct {
+	net.Conn
+}
+
+func (panicConn) Read([]byte) (int, error) {
+	panic("read failure")
+}
+
+func TestHandleConnectionPanic(t *testing.T) {
+	p, c := startDaemon(t, Config{})
+
+	mustStatus(t)(c.Toggle())
+
+	server, conn := net.Pipe()
+	defer conn.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		p.handleConnection(panicConn{server})
+	}()
+
+	var response protocol.Response
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	<-done
+
+	if response.Error != "Internal daemon error" || response.Status != nil {
+		t.Fatalf("response of a panicking handler = %+v, want Internal daemon error", response)
+	}
+
+	if status := mustStatus(t)(c.Status()); status.PeriodCode != protocol.Work {
+		t.Fatalf("get after the panic = %s, want Work", status.Period)
+	}
+}
+
+// panicClock panics on reading the time while armed, to make a bug
+// inside a command handler.
+type panicClock struct {
+	*fakeClock
+	armed atomic.Bool
+}
+
+func (c *panicClock) Now() time.Time {
+	if c.armed.Load() {
+		panic("clock failure")
+	}
+
+	return c.fakeClock.Now()
+}
+
+func TestHandlerPanic(t *testing.T) {
+	clock := &panicClock{fakeClock: newFakeClock()}
+	p, c := startDaemon(t, Config{Clock: clock})
+
+	mustStatus(t)(c.Toggle())
+
+	clock.armed.Store(true)
+	_, err := c.Status()
+	clock.armed.Store(false)
+
+	var daemonErr *client.DaemonError
+	if !errors.As(err, &daemonErr) || daemonErr.Message != errPanic.Error() {
+		t.Fatalf("get with a panicking handler = %v, want %q", err, errPanic)
+	}
+
+	status := mustStatus(t)(c.Status())
+	if status.PeriodCode != protocol.Work || status.RestOfTime != testWorkDuration {
+		t.Fatalf("get after the panic = %s %s, want Work %s", status.Period, status.RestOfTime, testWorkDuration)
+	}
+
+	clock.advance(t, p, testWorkDuration)
+
+	if status := mustStatus(t)(c.Status()); status.PeriodCode != protocol.Rest {
+		t.Fatalf("after the work period = %s, want Rest", status.Period)
+	}
+}
+
+// TestConcurrentGetAndToggle is meant for go test -race, every status a
+// reader gets has to be consistent whatever the togglers are doing.
+func TestConcurrentGetAndToggle(t *testing.T) {
+	_, c := startDaemon(t, Config{Tick: 10 * time.Millisecond})
+
+	const (
+		readers  = 8
+		togglers = 2
+		rounds   = 50
+	)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, readers+togglers)
+
+	for range togglers {
+		wg.Go(func() {
+			for range rounds {
+				if _, err := c.Toggle(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		})
+	}
+
+	for range readers {
+		wg.Go(func() {
+			for range rounds {
+				status, err := c.Status()
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				switch status.PeriodCode {
+				case protocol.Stopped:
+					if status.RestOfTime != 0 || status.Paused {
+						errs <- fmt.Errorf("stopped with %s left, paused %t", status.RestOfTime, status.Paused)
+						return
+					}
+				case protocol.Work:
+					if status.RestOfTime <= 0 || status.RestOfTime > testWorkDuration {
+						errs <- fmt.Errorf("work with %s left", status.RestOfTime)
+						return
+					}
+				default:
+					errs <- fmt.Errorf("unexpected period %s", status.Period)
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestReloadIsNotATransition(t *testing.T) {
+	_, c := startDaemon(t, Config{
+		Reload: func() (Config, error) {
+			return Config{WorkDuration: 5 * time.Minute, RestDuration: testRestDuration}, nil
+		},
+	})
+
+	before := mustStatus(t)(c.Toggle())
+
+	response, err := c.Command("reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Config == nil || response.Config.WorkDuration != 5*time.Minute {
+		t.Fatalf("reload = %+v, want a work duration of 5m", response.Config)
+	}
+
+	status := mustStatus(t)(c.Status())
+	if status.TransitionSeq != before.TransitionSeq || status.PeriodCode != protocol.Work {
+		t.Fatalf("after reload = %s #%d, want Work #%d", status.Period, status.TransitionSeq, before.TransitionSeq)
+	}
+}
+
+func TestStartRunsNoHook(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "hooks.log")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$POMODORO_PERIOD $POMODORO_PREVIOUS_PERIOD\" >> %q\n", log)
+
+	for _, name := range []string{"on-stop", "on-work-start"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, c := startDaemon(t, Config{HooksDir: dir})
+
+	// The work hook is started after any hook of the start would have been,
+	// so once it has written its line the log is complete.
+	mustStatus(t)(c.Toggle())
+
+	var data []byte
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		data, _ = os.ReadFile(log)
+		if strings.Contains(string(data), "Work") {
+			break
+		}
+	}
+
+	if got := string(data); got != "Work Stopped\n" {
+		t.Fatalf("hooks ran %q, want only the work hook after Stopped", got)
+	}
+}
+
+func TestWebhookFirstEventIsATransition(t *testing.T) {
+	events := make(chan webhookEvent, webhookQueueSize)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decoding webhook event: %v", err)
+		}
+
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+
+	_, c := startDaemon(t, Config{Webhooks: []string{server.URL}})
+
+	mustStatus(t)(c.Toggle())
+
+	select {
+	case event := <-events:
+		if event.Period != "Work" || event.PreviousPeriod != "Stopped" {
+			t.Fatalf("first webhook event = %s after %s, want Work after Stopped", event.Period, event.PreviousPeriod)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook event")
+	}
+}
@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/notify"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	dayFormat         = "2006-01-02"
+	archivedDaysLimit = 31
+)
+
+// rollOverDay archives the daily counter once the local calendar day
+// changes. Comparing dates rather than durations keeps DST shifts from
+// resetting the counter twice or not at all.
+func (p *Daemon) rollOverDay(now time.Time) {
+	today := now.Local().Format(dayFormat)
+	if p.counterDay == today {
+		return
+	}
+
+	if p.counterDay != "" {
+		p.archivedDays = append(p.archivedDays, protocol.DaySummary{Date: p.counterDay, Completed: p.completedToday})
+
+		if len(p.archivedDays) > archivedDaysLimit {
+			p.archivedDays = p.archivedDays[len(p.archivedDays)-archivedDaysLimit:]
+		}
+
+		p.logger.Info("daily counter rolled over", "day", p.counterDay, "completed", p.completedToday)
+	}
+
+	p.counterDay = today
+	p.completedToday = 0
+}
+
+func (p *Daemon) countCompletedWork() {
+	p.rollOverDay(p.clock.Now())
+
+	p.completedWorkSessions++
+	p.completedToday++
+	p.cycleSessions++
+
+	if p.goal > 0 && p.completedToday == p.goal {
+		p.logger.Info("daily goal reached", "goal", p.goal)
+
+		if !p.notificationsMuted(p.clock.Now()) {
+			go p.sendNotification(notify.Notification{
+				Title:   p.catalog.Text("notification.goal-title"),
+				Message: fmt.Sprintf(p.catalog.Text("notification.goal-message"), p.goal),
+			})
+		}
+	}
+}
+
+func (p *Daemon) uncountCompletedWork() {
+	p.completedWorkSessions = max(p.completedWorkSessions-1, 0)
+	p.completedToday = max(p.completedToday-1, 0)
+	p.cycleSessions = max(p.cycleSessions-1, 0)
+}
+
+func (p *Daemon) getArchivedDays() []protocol.DaySummary {
+	days := make([]protocol.DaySummary, len(p.archivedDays))
+	copy(days, p.archivedDays)
+
+	return days
+}
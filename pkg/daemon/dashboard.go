@@ -0,0 +1,18 @@
+package daemon
+
+import (
+	"embed"
+	"net/http"
+)
+
+// dashboardFiles is the single page dashboard served under /dashboard/.
+// The page holds no data, it calls the REST API with the auth token the
+// user enters, so it is served without one.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+func handleDashboard(mux *http.ServeMux) {
+	mux.Handle("GET /dashboard/", http.FileServerFS(dashboardFiles))
+	mux.Handle("GET /{$}", http.RedirectHandler("/dashboard/", http.StatusFound))
+}
@@ -0,0 +1,160 @@
+"use strict";
+
+// Period codes of protocol.Period.
+const WORK = 1, REST = 2, WAITING = 4, PREPARE = 5, LONG_REST = 6;
+const NANOSECONDS_PER_MINUTE = 60e9;
+
+const $ = (id) => document.getElementById(id);
+
+let status = null;
+let transitionSeq = null;
+
+function headers() {
+  const token = localStorage.getItem("pomodoro-token");
+  return token ? { Authorization: "Bearer " + token } : {};
+}
+
+async function request(method, path) {
+  const response = await fetch(path, { method, headers: headers() });
+  if (response.status === 401) {
+    $("login").hidden = false;
+    throw new Error("Invalid auth token");
+  }
+
+  const body = await response.json();
+  if (body.error) {
+    throw new Error(body.error);
+  }
+
+  return body;
+}
+
+function showError(err) {
+  $("error").textContent = err ? err.message : "";
+}
+
+function render() {
+  const main = document.querySelector("main");
+  const period = status.paused && status.paused_period ? status.paused_period + " (paused)" : status.period;
+
+  main.className = [WORK, PREPARE].includes(status.period_code) ? "work"
+    : [REST, LONG_REST].includes(status.period_code) ? "rest" : "";
+
+  $("period").textContent = status.tag ? period + " · " + status.tag : period;
+  $("countdown").textContent = status.rest_of_time_str;
+  $("progress").style.width = status.progress_percent + "%";
+  $("pause").textContent = status.paused ? "Resume" : "Pause";
+  $("continue").hidden = status.period_code !== WAITING;
+  $("completed").textContent = status.goal ? status.completed_today + "/" + status.goal : status.completed_today;
+
+  document.title = status.rest_of_time_str + " · Pomodoro";
+}
+
+function minutes(nanoseconds) {
+  const total = Math.round(nanoseconds / NANOSECONDS_PER_MINUTE);
+  return total >= 60 ? Math.floor(total / 60) + "h " + (total % 60) + "m" : total + "m";
+}
+
+async function loadStats() {
+  const { history = [] } = await request("GET", "/history");
+  const today = new Date().toDateString();
+
+  let focus = 0, breaks = 0, skipped = 0;
+
+  for (const entry of history) {
+    if (new Date(entry.started_at).toDateString() !== today) {
+      continue;
+    }
+
+    if (entry.skipped) {
+      skipped++;
+    }
+
+    if (entry.period === "Work") {
+      focus += entry.duration - (entry.idle || 0);
+    } else if (entry.period === "Rest" || entry.period === "LongRest") {
+      breaks += entry.duration;
+    }
+  }
+
+  $("focus").textContent = minutes(focus);
+  $("breaks").textContent = minutes(breaks);
+  $("skipped").textContent = skipped;
+}
+
+// subscribe reads the server-sent events with fetch, EventSource cannot
+// send the auth token.
+async function subscribe() {
+  const response = await fetch("/events?granularity=second", { headers: headers() });
+  if (response.status === 401) {
+    $("login").hidden = false;
+    throw new Error("Invalid auth token");
+  }
+
+  const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffer = "";
+
+  showError(null);
+
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) {
+      throw new Error("Daemon closed the connection");
+    }
+
+    buffer += value;
+
+    let end;
+    while ((end = buffer.indexOf("\n\n")) >= 0) {
+      const event = buffer.slice(0, end);
+      buffer = buffer.slice(end + 2);
+
+      const data = event.split("\n").filter((line) => line.startsWith("data: ")).map((line) => line.slice(6)).join("\n");
+      if (!data) {
+        continue;
+      }
+
+      status = JSON.parse(data);
+      render();
+
+      if (status.transition_seq !== transitionSeq) {
+        transitionSeq = status.transition_seq;
+        loadStats().catch(showError);
+      }
+    }
+  }
+}
+
+async function connect() {
+  for (;;) {
+    try {
+      await subscribe();
+    } catch (err) {
+      showError(err);
+    }
+
+    await new Promise((resolve) => setTimeout(resolve, 2000));
+  }
+}
+
+function button(id, path) {
+  $(id).addEventListener("click", () => request("POST", path).then(() => showError(null), showError));
+}
+
+button("toggle", "/toggle");
+button("skip", "/skip");
+button("continue", "/continue");
+
+$("pause").addEventListener("click", () => {
+  const path = status && status.paused ? "/resume" : "/pause";
+  request("POST", path).then(() => showError(null), showError);
+});
+
+$("login").addEventListener("submit", (event) => {
+  event.preventDefault();
+  localStorage.setItem("pomodoro-token", $("token").value);
+  $("login").hidden = true;
+  showError(null);
+});
+
+connect();
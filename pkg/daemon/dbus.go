@@ -0,0 +1,168 @@
+//go:build !freebsd
+
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	dbusServiceName  = "org.thek4n.Pomodoro"
+	dbusServicePath  = "/org/thek4n/Pomodoro"
+	dbusInterface    = "org.thek4n.Pomodoro"
+	dbusStateChanged = dbusInterface + ".StateChanged"
+	dbusQueueSize    = 16
+)
+
+// dbusService exposes the daemon on the session bus. Methods return the
+// status as JSON, the same document get --format json and subscribe print,
+// and StateChanged carries it on every transition.
+type dbusService struct {
+	p       *Daemon
+	conn    *dbus.Conn
+	signals chan protocol.Status
+	gnome   *gnomePomodoro
+}
+
+// connectDBus claims org.thek4n.Pomodoro, and org.gnome.Pomodoro as well
+// when gnome is set.
+func (p *Daemon) connectDBus(gnome bool) (*dbusService, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+
+	s := &dbusService{p: p, conn: conn, signals: make(chan protocol.Status, dbusQueueSize)}
+
+	if err := s.export(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if gnome {
+		s.gnome = &gnomePomodoro{s: s}
+
+		if err := s.gnome.export(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	go s.emitSignals()
+
+	return s, nil
+}
+
+func (s *dbusService) export() error {
+	if err := requestName(s.conn, dbusServiceName); err != nil {
+		return err
+	}
+
+	node := &introspect.Node{
+		Name: dbusServicePath,
+		Interfaces: []introspect.Interface{{
+			Name:    dbusInterface,
+			Methods: introspect.Methods(s),
+			Signals: []introspect.Signal{{
+				Name: "StateChanged",
+				Args: []introspect.Arg{{Name: "status", Type: "s"}},
+			}},
+		}},
+	}
+
+	if err := s.conn.Export(s, dbusServicePath, dbusInterface); err != nil {
+		return fmt.Errorf("failed to export %s: %w", dbusInterface, err)
+	}
+
+	return exportIntrospection(s.conn, node)
+}
+
+func requestName(conn *dbus.Conn, name string) error {
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("failed to request %s: %w", name, err)
+	}
+
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("%s is already owned by another process", name)
+	}
+
+	return nil
+}
+
+func exportIntrospection(conn *dbus.Conn, node *introspect.Node) error {
+	if err := conn.Export(introspect.NewIntrospectable(node), dbus.ObjectPath(node.Name), "org.freedesktop.DBus.Introspectable"); err != nil {
+		return fmt.Errorf("failed to export introspection data: %w", err)
+	}
+
+	return nil
+}
+
+func (s *dbusService) GetStatus() (string, *dbus.Error) { return s.call("get") }
+func (s *dbusService) Toggle() (string, *dbus.Error)    { return s.call("switch") }
+func (s *dbusService) Pause() (string, *dbus.Error)     { return s.call("pause") }
+func (s *dbusService) Resume() (string, *dbus.Error)    { return s.call("resume") }
+func (s *dbusService) Skip() (string, *dbus.Error)      { return s.call("skip") }
+
+func (s *dbusService) call(command string) (string, *dbus.Error) {
+	response := s.p.runCommand(protocol.Request{Cmd: command})
+	if response.Error != "" {
+		return "", dbus.MakeFailedError(errors.New(response.Error))
+	}
+
+	data, err := json.Marshal(response.Status)
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+
+	return string(data), nil
+}
+
+func (p *Daemon) emitDBus() {
+	if p.dbus != nil {
+		p.dbus.stateChanged(p.status())
+	}
+}
+
+// stateChanged queues the signals of a transition, a bus that cannot keep
+// up misses signals instead of blocking the event loop.
+func (s *dbusService) stateChanged(status protocol.Status) {
+	select {
+	case s.signals <- status:
+	default:
+	}
+}
+
+func (s *dbusService) emitSignals() {
+	for status := range s.signals {
+		data, err := json.Marshal(status)
+		if err != nil {
+			s.p.logger.Error("failed to encode status for dbus", "error", err)
+			continue
+		}
+
+		s.emit(dbusServicePath, dbusStateChanged, string(data))
+
+		if s.gnome != nil {
+			s.gnome.stateChanged(status)
+		}
+	}
+}
+
+func (s *dbusService) emit(path dbus.ObjectPath, name string, values ...any) {
+	if err := s.conn.Emit(path, name, values...); err != nil {
+		s.p.logger.Warn("failed to emit dbus signal", "signal", name, "error", err)
+	}
+}
+
+func (s *dbusService) close() {
+	close(s.signals)
+	_ = s.conn.Close()
+}
@@ -0,0 +1,26 @@
+package daemon
+
+import (
+	"errors"
+)
+
+const (
+	dbusServiceName  = "org.thek4n.Pomodoro"
+	gnomeServiceName = "org.gnome.Pomodoro"
+)
+
+type dbusService struct {
+	gnome *gnomePomodoro
+}
+
+type gnomePomodoro struct{}
+
+// connectDBus refuses --dbus and --gnome-pomodoro, godbus does not build
+// on FreeBSD.
+func (p *Daemon) connectDBus(gnome bool) (*dbusService, error) {
+	return nil, errors.New("the session bus is not supported on FreeBSD")
+}
+
+func (p *Daemon) emitDBus() {}
+
+func (s *dbusService) close() {}
@@ -0,0 +1,234 @@
+//go:build !freebsd
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// The org.gnome.Pomodoro interface of gnome-pomodoro, which GNOME Shell
+// extensions and other integrations are written against.
+const (
+	gnomeServiceName       = "org.gnome.Pomodoro"
+	gnomeServicePath       = "/org/gnome/Pomodoro"
+	gnomeInterface         = "org.gnome.Pomodoro"
+	gnomePropertiesChanged = "org.freedesktop.DBus.Properties.PropertiesChanged"
+
+	// gnomeVersion is the gnome-pomodoro release whose interface is
+	// implemented.
+	gnomeVersion = "0.24.0"
+)
+
+// gnome-pomodoro state names.
+const (
+	gnomeStateNull       = "null"
+	gnomeStatePomodoro   = "pomodoro"
+	gnomeStateShortBreak = "short-break"
+	gnomeStateLongBreak  = "long-break"
+)
+
+type gnomePomodoro struct {
+	s    *dbusService
+	last map[string]dbus.Variant
+}
+
+func (g *gnomePomodoro) export() error {
+	if err := requestName(g.s.conn, gnomeServiceName); err != nil {
+		return err
+	}
+
+	if err := g.s.conn.Export(g, gnomeServicePath, gnomeInterface); err != nil {
+		return fmt.Errorf("failed to export %s: %w", gnomeInterface, err)
+	}
+
+	if err := g.s.conn.Export(gnomeProperties{g}, gnomeServicePath, "org.freedesktop.DBus.Properties"); err != nil {
+		return fmt.Errorf("failed to export %s properties: %w", gnomeInterface, err)
+	}
+
+	node := &introspect.Node{
+		Name: gnomeServicePath,
+		Interfaces: []introspect.Interface{
+			prop.IntrospectData,
+			{
+				Name:    gnomeInterface,
+				Methods: introspect.Methods(g),
+				Properties: []introspect.Property{
+					{Name: "Elapsed", Type: "d", Access: "read"},
+					{Name: "State", Type: "s", Access: "read"},
+					{Name: "StateDuration", Type: "d", Access: "read"},
+					{Name: "IsPaused", Type: "b", Access: "read"},
+					{Name: "Version", Type: "s", Access: "read"},
+				},
+			},
+		},
+	}
+
+	return exportIntrospection(g.s.conn, node)
+}
+
+func gnomeState(period protocol.Period) string {
+	switch period {
+	case protocol.Work:
+		return gnomeStatePomodoro
+	case protocol.Rest:
+		return gnomeStateShortBreak
+	case protocol.LongRest:
+		return gnomeStateLongBreak
+	default:
+		return gnomeStateNull
+	}
+}
+
+func gnomeProps(status protocol.Status) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Elapsed":       dbus.MakeVariant((status.PeriodDuration - status.RestOfTime).Seconds()),
+		"State":         dbus.MakeVariant(gnomeState(status.PeriodCode)),
+		"StateDuration": dbus.MakeVariant(status.PeriodDuration.Seconds()),
+		"IsPaused":      dbus.MakeVariant(status.Paused),
+		"Version":       dbus.MakeVariant(gnomeVersion),
+	}
+}
+
+// stateChanged emits PropertiesChanged with the properties that differ
+// from the last transition. Elapsed changes all the time and is only
+// read on demand, as with gnome-pomodoro.
+func (g *gnomePomodoro) stateChanged(status protocol.Status) {
+	props := gnomeProps(status)
+	changed := make(map[string]dbus.Variant)
+
+	for name, value := range props {
+		if name == "Elapsed" {
+			continue
+		}
+
+		if last, ok := g.last[name]; !ok || last.String() != value.String() {
+			changed[name] = value
+		}
+	}
+
+	g.last = props
+
+	if len(changed) > 0 {
+		g.s.emit(gnomeServicePath, gnomePropertiesChanged, gnomeInterface, changed, []string{})
+	}
+}
+
+func (g *gnomePomodoro) status() (protocol.Status, *dbus.Error) {
+	response := g.s.p.runCommand(protocol.Request{Cmd: "get"})
+	if response.Error != "" {
+		return protocol.Status{}, dbus.MakeFailedError(errors.New(response.Error))
+	}
+
+	return *response.Status, nil
+}
+
+func (g *gnomePomodoro) command(command string) *dbus.Error {
+	response := g.s.p.runCommand(protocol.Request{Cmd: command})
+	if response.Error != "" {
+		return dbus.MakeFailedError(errors.New(response.Error))
+	}
+
+	return nil
+}
+
+// Start begins a pomodoro unless the timer is already running.
+func (g *gnomePomodoro) Start() *dbus.Error {
+	status, err := g.status()
+	if err != nil {
+		return err
+	}
+
+	if status.PeriodCode != protocol.Stopped && status.PeriodCode != protocol.Waiting {
+		return nil
+	}
+
+	return g.command("switch")
+}
+
+func (g *gnomePomodoro) Stop() *dbus.Error {
+	status, err := g.status()
+	if err != nil {
+		return err
+	}
+
+	if status.PeriodCode == protocol.Stopped {
+		return nil
+	}
+
+	return g.command("switch")
+}
+
+// Reset starts the cycle over from its first pomodoro.
+func (g *gnomePomodoro) Reset() *dbus.Error { return g.command("restart") }
+
+func (g *gnomePomodoro) Pause() *dbus.Error  { return g.command("pause") }
+func (g *gnomePomodoro) Resume() *dbus.Error { return g.command("resume") }
+func (g *gnomePomodoro) Skip() *dbus.Error   { return g.command("skip") }
+
+// SetState only supports stopping the timer with the null state, the
+// daemon moves between the other states by itself.
+func (g *gnomePomodoro) SetState(state string, timestamp float64) *dbus.Error {
+	if state != gnomeStateNull {
+		return dbus.MakeFailedError(fmt.Errorf("setting the state to %q is not supported", state))
+	}
+
+	return g.Stop()
+}
+
+func (g *gnomePomodoro) SetStateDuration(state string, duration float64) *dbus.Error {
+	return dbus.MakeFailedError(errors.New("setting the state duration is not supported"))
+}
+
+// ShowMainWindow and ShowPreferences do nothing, the daemon has no windows.
+func (g *gnomePomodoro) ShowMainWindow(mode string, timestamp uint32) *dbus.Error { return nil }
+func (g *gnomePomodoro) ShowPreferences(timestamp uint32) *dbus.Error             { return nil }
+
+// Quit stops the daemon like stop-daemon.
+func (g *gnomePomodoro) Quit() *dbus.Error {
+	g.s.p.Shutdown()
+	return nil
+}
+
+// gnomeProperties implements org.freedesktop.DBus.Properties for the
+// gnome object, reading the values from the current status.
+type gnomeProperties struct {
+	g *gnomePomodoro
+}
+
+func (p gnomeProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	value, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, prop.ErrPropNotFound
+	}
+
+	return value, nil
+}
+
+func (p gnomeProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface != gnomeInterface {
+		return nil, prop.ErrIfaceNotFound
+	}
+
+	status, err := p.g.status()
+	if err != nil {
+		return nil, err
+	}
+
+	return gnomeProps(status), nil
+}
+
+func (p gnomeProperties) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	return prop.ErrReadOnly
+}
@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// recordCurrentPeriod adds the ending period to the history.
+func (p *Daemon) recordCurrentPeriod() {
+	if entry, ok := p.finishPeriodEntry(); ok {
+		p.addHistory(entry)
+	}
+}
+
+// finishPeriodEntry returns the history entry of the ending period, none
+// when the timer was stopped or waiting. A period counts as skipped when it
+// ends before its countdown ran out.
+func (p *Daemon) finishPeriodEntry() (protocol.HistoryEntry, bool) {
+	remaining := p.remaining()
+	skipped := p.skipped || remaining > 0
+	suspended := p.suspendedFor
+	idle := p.periodIdle
+	p.skipped = false
+	p.suspendedFor = 0
+	p.periodIdle = 0
+
+	if p.currentPeriod == protocol.Stopped || p.currentPeriod == protocol.Waiting {
+		return protocol.HistoryEntry{}, false
+	}
+
+	entry := protocol.HistoryEntry{
+		Period:    p.currentPeriod.String(),
+		StartedAt: p.periodStartedAt,
+		EndedAt:   p.clock.Now(),
+		Duration:  p.currentPeriodDuration - remaining,
+		Skipped:   skipped,
+		Suspended: suspended,
+		Idle:      idle,
+		Task:      p.finishTask(),
+		Tag:       p.tag,
+	}
+
+	if p.currentPeriod == protocol.Work {
+		p.focusTime += entry.Duration - entry.Idle
+	}
+
+	return entry, true
+}
+
+func (p *Daemon) addHistory(entry protocol.HistoryEntry) {
+	p.appendHistoryFile(entry)
+	p.lastEntry = entry
+
+	if p.historySize <= 0 {
+		return
+	}
+
+	p.history = append(p.history, entry)
+
+	if len(p.history) > p.historySize {
+		p.history = p.history[len(p.history)-p.historySize:]
+	}
+}
+
+// skipContinuedWork marks the entry of the work period a snooze continues
+// as skipped, so the pomodoro is counted once, by the entry of the snoozed
+// part, like the daemon's counters count it.
+func (p *Daemon) skipContinuedWork() {
+	if p.overtimeEntry != nil {
+		p.overtimeEntry.Skipped = true
+		return
+	}
+
+	if p.lastEntry.Period != protocol.Work.String() || p.lastEntry.Skipped {
+		return
+	}
+
+	entry := p.lastEntry
+	entry.Skipped = true
+
+	p.replaceHistoryFileEntry(p.lastEntry, entry)
+	p.lastEntry = entry
+
+	if len(p.history) > 0 {
+		p.history[len(p.history)-1] = entry
+	}
+}
+
+func (p *Daemon) getHistory() []protocol.HistoryEntry {
+	history := make([]protocol.HistoryEntry, len(p.history))
+	copy(history, p.history)
+
+	return history
+}
@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// appendHistoryFile appends the entry as a JSON line, so the file
+// keeps every period across restarts.
+func (p *Daemon) appendHistoryFile(entry protocol.HistoryEntry) {
+	if p.historyFile == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		p.logger.Error("failed to encode history entry", "error", err)
+		return
+	}
+
+	if err := appendLine(p.historyFile, data); err != nil {
+		p.logger.Warn("failed to write history file", "file", p.historyFile, "error", err)
+	}
+}
+
+// replaceHistoryFileEntry rewrites the last line of the history file, which
+// has to hold old, with entry.
+func (p *Daemon) replaceHistoryFileEntry(old, entry protocol.HistoryEntry) {
+	if p.historyFile == "" {
+		return
+	}
+
+	oldData, err := json.Marshal(old)
+	if err != nil {
+		p.logger.Error("failed to encode history entry", "error", err)
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		p.logger.Error("failed to encode history entry", "error", err)
+		return
+	}
+
+	if err := replaceLastLine(p.historyFile, oldData, data); err != nil {
+		p.logger.Warn("failed to write history file", "file", p.historyFile, "error", err)
+	}
+}
+
+func appendLine(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// replaceLastLine replaces the last line of the file, which has to be old,
+// with data.
+func replaceLastLine(path string, old, data []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	old = append(old, '\n')
+
+	offset := info.Size() - int64(len(old))
+	if offset < 0 {
+		return errors.New("the last line has changed")
+	}
+
+	last := make([]byte, len(old))
+	if _, err := f.ReadAt(last, offset); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(last, old) {
+		return errors.New("the last line has changed")
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+
+	if _, err := f.WriteAt(append(data, '\n'), offset); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const hookTimeout = 30 * time.Second
+
+func hookName(period protocol.Period) string {
+	switch period {
+	case protocol.Work:
+		return "on-work-start"
+	case protocol.Rest, protocol.LongRest:
+		return "on-rest-start"
+	case protocol.Stopped:
+		return "on-stop"
+	default:
+		return ""
+	}
+}
+
+// runHooks runs the hook of the current period once the daemon has
+// entered it. Missing hooks are skipped silently.
+func (p *Daemon) runHooks() {
+	if p.hooksDir == "" || p.currentPeriod == p.hookPeriod {
+		return
+	}
+
+	previous := p.hookPeriod
+	p.hookPeriod = p.currentPeriod
+
+	name := hookName(p.currentPeriod)
+	if name == "" {
+		return
+	}
+
+	hook := filepath.Join(p.hooksDir, name)
+
+	info, err := os.Stat(hook)
+	if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+		return
+	}
+
+	env := append(os.Environ(),
+		"POMODORO_PERIOD="+p.currentPeriod.String(),
+		"POMODORO_PREVIOUS_PERIOD="+previous.String(),
+		"POMODORO_DURATION="+strconv.Itoa(int(p.remaining().Seconds())),
+		"POMODORO_CYCLE="+strconv.Itoa(p.completedWorkSessions),
+		"POMODORO_SOCKET="+p.socketPath,
+		"POMODORO_TIMER="+p.name,
+	)
+
+	go p.runHook(hook, env)
+}
+
+func (p *Daemon) runHook(hook string, env []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Env = env
+	cmd.WaitDelay = time.Second
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", hookTimeout)
+		}
+
+		p.logger.Warn("hook failed",
+			"hook", hook,
+			"error", err,
+			"output", string(output),
+		)
+	}
+}
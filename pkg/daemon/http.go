@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// httpCommands maps the POST endpoints of the REST API to socket commands.
+var httpCommands = map[string]string{
+	"toggle":   "switch",
+	"pause":    "pause",
+	"resume":   "resume",
+	"skip":     "skip",
+	"snooze":   "snooze",
+	"continue": "continue",
+	"ack":      "ack",
+	"restart":  "restart",
+	"reset":    "reset",
+}
+
+// listenHTTP starts the REST API and the dashboard on p.httpAddress. The
+// server is closed by the returned function.
+func (p *Daemon) listenHTTP() (func(), error) {
+	listener, err := net.Listen("tcp", p.httpAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", p.httpAddress, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", p.handleHTTPCommand("get"))
+	mux.HandleFunc("GET /config", p.handleHTTPCommand("config-get"))
+	mux.HandleFunc("GET /history", p.handleHTTPCommand("history"))
+	mux.HandleFunc("GET /events", p.handleHTTPEvents)
+
+	for path, command := range httpCommands {
+		mux.HandleFunc("POST /"+path, p.handleHTTPCommand(command))
+	}
+
+	root := http.NewServeMux()
+	handleDashboard(root)
+	root.Handle("/", p.httpAuthorized(mux))
+
+	// Without a token any web page could post to a loopback API, so
+	// commands from other origins are rejected.
+	server := &http.Server{
+		Handler:           http.NewCrossOriginProtection().Handler(root),
+		ReadHeaderTimeout: p.connTimeout,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			p.logger.Error("http server failed", "error", err)
+		}
+	}()
+
+	return func() { _ = server.Close() }, nil
+}
+
+// httpAuthorized requires the daemon's token as a bearer token when one is
+// configured.
+func (p *Daemon) httpAuthorized(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if p.authToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(p.authToken)) != 1 {
+			p.logger.Warn("rejected http request with invalid auth token", "path", r.URL.Path)
+			writeHTTPResponse(w, http.StatusUnauthorized, protocol.Response{Error: "Invalid auth token"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *Daemon) handleHTTPCommand(command string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := p.runCommand(protocol.Request{Cmd: command})
+
+		code := http.StatusOK
+		if response.Error != "" {
+			code = http.StatusConflict
+		}
+
+		writeHTTPResponse(w, code, response)
+	}
+}
+
+// handleHTTPEvents streams status updates as server-sent events, the
+// granularity query parameter works like the one of subscribe.
+func (p *Daemon) handleHTTPEvents(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	err := p.stream(r.URL.Query().Get("granularity"), r.Context().Done(), func(status protocol.Status) error {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+
+		_ = controller.SetWriteDeadline(time.Now().Add(p.connTimeout))
+
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+
+		return controller.Flush()
+	})
+	if err != nil {
+		writeHTTPResponse(w, http.StatusBadRequest, protocol.Response{Error: err.Error()})
+	}
+}
+
+func writeHTTPResponse(w http.ResponseWriter, code int, response protocol.Response) {
+	response.Version = protocol.Version
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
+}
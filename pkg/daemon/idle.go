@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	idleCheckInterval = 5 * time.Second
+	idleCheckTimeout  = 5 * time.Second
+)
+
+// checkIdle asks the idle command how long the user has been away, like
+// xprintidle does through the XScreenSaver extension.
+func (p *Daemon) checkIdle(ctx context.Context) {
+	if !p.idleChecking.CompareAndSwap(false, true) {
+		return
+	}
+	defer p.idleChecking.Store(false)
+
+	idleFor, err := p.runIdleCheck(ctx)
+	if err != nil {
+		// The check runs every few seconds, so only the first failure in
+		// a row is worth a warning.
+		if p.idleCheckFailing.CompareAndSwap(false, true) {
+			p.logger.Warn("idle check failed", "cmd", p.idleCmd, "error", err)
+		}
+
+		return
+	}
+
+	p.idleCheckFailing.Store(false)
+	p.do(func() { p.applyIdle(idleFor >= p.idleThreshold, idleFor) })
+}
+
+// runIdleCheck reads the idle time in milliseconds from the idle command.
+func (p *Daemon) runIdleCheck(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, idleCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", p.idleCmd).Output()
+	if err != nil {
+		return 0, err
+	}
+
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle time %q", strings.TrimSpace(string(output)))
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// applyIdle pauses a running work period once per idle stretch and
+// resumes it on activity. The part of the stretch the timer ran through
+// is kept as the idle time of the period, so it does not count as focus.
+func (p *Daemon) applyIdle(idle bool, idleFor time.Duration) {
+	if !idle {
+		p.idlePausedIn = time.Time{}
+
+		if p.idlePaused {
+			p.idlePaused = false
+
+			if p.paused {
+				p.logger.Info("active again, resuming the timer")
+				p.setPaused(false)
+				p.onStateChange()
+			}
+		}
+
+		return
+	}
+
+	if !p.isTicking() || p.currentPeriod != protocol.Work || p.idlePausedIn.Equal(p.periodStartedAt) {
+		return
+	}
+
+	p.idlePausedIn = p.periodStartedAt
+	p.periodIdle += min(idleFor, p.currentPeriodDuration-p.remaining())
+
+	p.logger.Info("idle, pausing the timer", "idle", idleFor)
+	p.setPaused(true)
+	p.idlePaused = true
+	p.onStateChange()
+}
+
+// handleIdleRequest takes idle and active from an idle daemon, e.g.
+// swayidle timeout 300 'pomodoro idle 5m' resume 'pomodoro active' on
+// Wayland. Without a duration the user is taken to be away for the idle
+// threshold.
+func (p *Daemon) handleIdleRequest(request protocol.Request) (protocol.Status, error) {
+	if request.Cmd == "active" {
+		p.applyIdle(false, 0)
+		return p.status(), nil
+	}
+
+	idleFor := p.idleThreshold
+
+	if value := request.Args["duration"]; value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return protocol.Status{}, fmt.Errorf("invalid duration %q", value)
+		}
+
+		idleFor = duration
+	}
+
+	p.applyIdle(true, idleFor)
+
+	return p.status(), nil
+}
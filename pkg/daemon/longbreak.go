@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	breakTypeShort = "short"
+	breakTypeLong  = "long"
+)
+
+func isBreak(period protocol.Period) bool {
+	return period == protocol.Rest || period == protocol.LongRest
+}
+
+// longBreakDue reports whether the work periods of the current cycle have
+// earned a long break.
+func (p *Daemon) longBreakDue() bool {
+	return p.longBreakInterval > 0 && p.cycleSessions >= p.longBreakInterval
+}
+
+func (p *Daemon) sessionsUntilLongBreak() int {
+	if p.longBreakInterval <= 0 {
+		return 0
+	}
+
+	return max(p.longBreakInterval-p.cycleSessions, 0)
+}
+
+// breakType names the active break, or the pending one while waiting for
+// confirmation.
+func (p *Daemon) breakType() string {
+	period := p.currentPeriod
+	if period == protocol.Waiting {
+		period = p.nextPeriod
+	}
+
+	switch period {
+	case protocol.Rest:
+		return breakTypeShort
+	case protocol.LongRest:
+		return breakTypeLong
+	default:
+		return ""
+	}
+}
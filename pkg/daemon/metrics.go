@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// metricsPeriods are reported by pomodoro_period, one series per period
+// with the current one set to 1.
+var metricsPeriods = []protocol.Period{
+	protocol.Stopped,
+	protocol.Waiting,
+	protocol.Prepare,
+	protocol.Work,
+	protocol.Rest,
+	protocol.LongRest,
+}
+
+type metrics struct {
+	period         protocol.Period
+	paused         bool
+	remaining      time.Duration
+	completedToday int
+	completed      int
+	focusTime      time.Duration
+	uptime         time.Duration
+}
+
+// listenMetrics serves the Prometheus text format on p.metricsAddress. The
+// server is closed by the returned function.
+func (p *Daemon) listenMetrics() (func(), error) {
+	listener, err := net.Listen("tcp", p.metricsAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", p.metricsAddress, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", p.handleMetrics)
+
+	server := &http.Server{
+		Handler:           p.httpAuthorized(mux),
+		ReadHeaderTimeout: p.connTimeout,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			p.logger.Error("metrics server failed", "error", err)
+		}
+	}()
+
+	return func() { _ = server.Close() }, nil
+}
+
+func (p *Daemon) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var m metrics
+
+	err := p.do(func() {
+		m = metrics{
+			period:         p.currentPeriod,
+			paused:         p.paused,
+			remaining:      p.remaining(),
+			completedToday: p.completedToday,
+			completed:      p.completedWorkSessions,
+			focusTime:      p.focusTime,
+			uptime:         p.clock.Now().Sub(p.startedAt),
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+func (m metrics) write(w io.Writer) {
+	writeMetricHeader(w, "pomodoro_period", "gauge", "Current period of the timer.")
+	for _, period := range metricsPeriods {
+		fmt.Fprintf(w, "pomodoro_period{period=%q} %d\n", strings.ToLower(period.String()), boolMetric(period == m.period))
+	}
+
+	writeMetric(w, "pomodoro_paused", "gauge", "Whether the timer is paused.", boolMetric(m.paused))
+	writeMetric(w, "pomodoro_remaining_seconds", "gauge", "Seconds left in the current period.", m.remaining.Seconds())
+	writeMetric(w, "pomodoro_completed_today", "gauge", "Work periods completed today.", m.completedToday)
+	writeMetric(w, "pomodoro_completed_sessions", "gauge", "Work periods completed since the cycle was last restarted.", m.completed)
+	writeMetric(w, "pomodoro_focus_seconds_total", "counter", "Seconds spent in ended work periods since the daemon started.", m.focusTime.Seconds())
+	writeMetric(w, "pomodoro_uptime_seconds", "gauge", "Seconds since the daemon started.", m.uptime.Seconds())
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeMetric(w io.Writer, name, kind, help string, value any) {
+	writeMetricHeader(w, name, kind, help)
+	fmt.Fprintf(w, "%s %v\n", name, value)
+}
+
+func boolMetric(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
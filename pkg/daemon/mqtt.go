@@ -0,0 +1,279 @@
+package daemon
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	mqttDefaultPort     = "1883"
+	mqttKeepAlive       = 60 * time.Second
+	mqttDialTimeout     = 5 * time.Second
+	mqttMinBackoff      = 1 * time.Second
+	mqttMaxBackoff      = 30 * time.Second
+	mqttQueueSize       = 16
+	mqttProtocolLevel   = 4
+	mqttCleanSession    = 0x02
+	mqttPacketConnect   = 0x10
+	mqttPacketConnack   = 0x20
+	mqttPacketPublish   = 0x30
+	mqttPacketPingreq   = 0xC0
+	mqttRetainFlag      = 0x01
+	mqttMaxRemainingLen = 268435455
+)
+
+type mqttPublisher struct {
+	address         string
+	topic           string
+	transitionTopic string
+	granularity     string
+	last            protocol.Status
+	clientID        string
+	logger          *slog.Logger
+	messages        chan mqttMessage
+}
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+func newMQTTPublisher(broker, topic, transitionTopic, granularity string, logger *slog.Logger) *mqttPublisher {
+	address := strings.TrimPrefix(broker, "tcp://")
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, mqttDefaultPort)
+	}
+
+	return &mqttPublisher{
+		address:         address,
+		topic:           topic,
+		transitionTopic: transitionTopic,
+		granularity:     granularity,
+		clientID:        fmt.Sprintf("pomodoro-%d", os.Getpid()),
+		logger:          logger,
+		messages:        make(chan mqttMessage, mqttQueueSize),
+	}
+}
+
+// Publish queues the message without blocking, dropping it if the broker
+// cannot keep up.
+func (m *mqttPublisher) Publish(message mqttMessage) {
+	select {
+	case m.messages <- message:
+	default:
+		m.logger.Warn("mqtt queue is full, dropping message", "topic", message.topic)
+	}
+}
+
+// run keeps a connection to the broker and publishes the queued messages
+// on it until ctx is done.
+func (m *mqttPublisher) run(ctx context.Context) {
+	backoff := mqttMinBackoff
+
+	var pending *mqttMessage
+
+	for {
+		conn, err := m.connect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			m.logger.Warn("failed to connect to mqtt broker", "broker", m.address, "error", err, "retry_in", backoff)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff = min(backoff*2, mqttMaxBackoff)
+
+			continue
+		}
+
+		m.logger.Info("connected to mqtt broker", "broker", m.address)
+		backoff = mqttMinBackoff
+
+		pending, err = m.serve(ctx, conn, pending)
+		_ = conn.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.logger.Warn("lost connection to mqtt broker", "broker", m.address, "error", err)
+	}
+}
+
+// serve publishes queued messages until the connection fails or ctx is
+// done, returning the message that could not be delivered so it is retried
+// after reconnect.
+func (m *mqttPublisher) serve(ctx context.Context, conn net.Conn, pending *mqttMessage) (*mqttMessage, error) {
+	closed := make(chan error, 1)
+
+	go func() {
+		_, err := io.Copy(io.Discard, conn)
+		if err == nil {
+			err = io.EOF
+		}
+		closed <- err
+	}()
+
+	ping := time.NewTicker(mqttKeepAlive / 2)
+	defer ping.Stop()
+
+	for {
+		if pending != nil {
+			header := byte(mqttPacketPublish)
+			if pending.retain {
+				header |= mqttRetainFlag
+			}
+
+			if err := m.writePacket(conn, header, publishBody(*pending)); err != nil {
+				return pending, err
+			}
+
+			pending = nil
+		}
+
+		select {
+		case message := <-m.messages:
+			pending = &message
+		case <-ping.C:
+			if err := m.writePacket(conn, mqttPacketPingreq, nil); err != nil {
+				return nil, err
+			}
+		case err := <-closed:
+			return nil, err
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		}
+	}
+}
+
+func (m *mqttPublisher) connect(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: mqttDialTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return nil, fmt.Errorf("error dialing broker: %w", err)
+	}
+
+	var body []byte
+	body = appendMQTTString(body, "MQTT")
+	body = append(body, mqttProtocolLevel, mqttCleanSession)
+	body = binary.BigEndian.AppendUint16(body, uint16(mqttKeepAlive/time.Second))
+	body = appendMQTTString(body, m.clientID)
+
+	if err := m.writePacket(conn, mqttPacketConnect, body); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(mqttDialTimeout))
+
+	connack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("error reading connack: %w", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	if connack[0] != mqttPacketConnack || connack[3] != 0 {
+		_ = conn.Close()
+		return nil, fmt.Errorf("broker refused connection, return code %d", connack[3])
+	}
+
+	return conn, nil
+}
+
+func publishBody(message mqttMessage) []byte {
+	body := appendMQTTString(nil, message.topic)
+
+	return append(body, message.payload...)
+}
+
+func (m *mqttPublisher) writePacket(conn net.Conn, header byte, body []byte) error {
+	if len(body) > mqttMaxRemainingLen {
+		return errors.New("mqtt packet is too large")
+	}
+
+	packet := append([]byte{header}, encodeMQTTLength(len(body))...)
+	packet = append(packet, body...)
+
+	if _, err := conn.Write(packet); err != nil {
+		return fmt.Errorf("error writing mqtt packet: %w", err)
+	}
+
+	return nil
+}
+
+// publishMQTT sends every transition to the transition topic and keeps the
+// retained status topic current at the configured granularity, so a home
+// automation can either react to events or read the state.
+func (p *Daemon) publishMQTT(transition bool) {
+	if p.mqtt == nil {
+		return
+	}
+
+	event := statusEvent{status: p.status(), transition: transition}
+	wantsStatus := wants(p.mqtt.granularity, event, p.mqtt.last)
+
+	if !transition && !wantsStatus {
+		return
+	}
+
+	payload, err := json.Marshal(event.status)
+	if err != nil {
+		p.logger.Error("failed to encode status for mqtt", "error", err)
+		return
+	}
+
+	if transition && p.mqtt.transitionTopic != "" {
+		p.mqtt.Publish(mqttMessage{topic: p.mqtt.transitionTopic, payload: payload})
+	}
+
+	if wantsStatus {
+		p.mqtt.Publish(mqttMessage{topic: p.mqtt.topic, payload: payload, retain: true})
+		p.mqtt.last = event.status
+	}
+}
+
+func appendMQTTString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+
+	return append(buf, s...)
+}
+
+func encodeMQTTLength(length int) []byte {
+	var encoded []byte
+
+	for {
+		digit := byte(length % 128)
+		length /= 128
+
+		if length > 0 {
+			digit |= 0x80
+		}
+
+		encoded = append(encoded, digit)
+
+		if length == 0 {
+			return encoded
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func (p *Daemon) notificationsMuted(now time.Time) bool {
+	return now.Before(p.mutedUntil)
+}
+
+func (p *Daemon) muteNotifications(duration time.Duration) (protocol.Status, error) {
+	if duration <= 0 {
+		return protocol.Status{}, fmt.Errorf("mute duration must be positive, got %s", duration)
+	}
+
+	p.mutedUntil = p.clock.Now().Add(duration)
+
+	return p.status(), nil
+}
+
+func (p *Daemon) unmuteNotifications() protocol.Status {
+	p.mutedUntil = time.Time{}
+
+	return p.status()
+}
+
+func (p *Daemon) handleMuteRequest(request protocol.Request) (protocol.Status, error) {
+	duration, err := time.ParseDuration(request.Args["duration"])
+	if err != nil {
+		return protocol.Status{}, fmt.Errorf("invalid mute duration: %w", err)
+	}
+
+	return p.muteNotifications(duration)
+}
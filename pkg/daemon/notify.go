@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/notify"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+type NotificationTemplate struct {
+	title   *template.Template
+	message *template.Template
+
+	// Expire and Urgency are passed on to the notifier.
+	Expire  time.Duration
+	Urgency string
+}
+
+// notificationData is available to the title and message templates.
+type notificationData struct {
+	Period         string
+	Duration       string
+	Remaining      string
+	Cycle          int
+	Tag            string
+	Completed      int
+	CompletedToday int
+	Goal           int
+}
+
+func ParseNotificationTemplate(name, title, message string) (NotificationTemplate, error) {
+	titleTemplate, err := template.New(name + "-title").Parse(title)
+	if err != nil {
+		return NotificationTemplate{}, fmt.Errorf("invalid %s title template: %w", name, err)
+	}
+
+	messageTemplate, err := template.New(name + "-message").Parse(message)
+	if err != nil {
+		return NotificationTemplate{}, fmt.Errorf("invalid %s message template: %w", name, err)
+	}
+
+	return NotificationTemplate{title: titleTemplate, message: messageTemplate}, nil
+}
+
+func (p *Daemon) notifyPeriod(period protocol.Period) {
+	if p.notificationsMuted(p.clock.Now()) {
+		p.logger.Debug("notification muted", "period", period.String(), "until", p.mutedUntil)
+		return
+	}
+
+	tmpl, ok := p.notifications[period]
+	if !ok {
+		p.logger.Debug("no notification for period", "period", period.String())
+		return
+	}
+
+	p.notify(tmpl, period, notificationData{
+		Duration:       protocol.FormatShortDuration(p.initialPeriodDurations[period]),
+		Cycle:          p.cycle(period),
+		Tag:            p.tag,
+		Completed:      p.completedWorkSessions,
+		CompletedToday: p.completedToday,
+		Goal:           p.goal,
+	})
+}
+
+// notify renders and sends the notification of the period, the templates
+// get the translated name of the period.
+func (p *Daemon) notify(tmpl NotificationTemplate, period protocol.Period, data notificationData) {
+	data.Period = p.catalog.Period(period.String())
+
+	title, err := renderTemplate(tmpl.title, data)
+	if err != nil {
+		p.logger.Error("failed to render notification title", "error", err)
+		return
+	}
+
+	message, err := renderTemplate(tmpl.message, data)
+	if err != nil {
+		p.logger.Error("failed to render notification message", "error", err)
+		return
+	}
+
+	go p.sendNotification(notify.Notification{
+		Title:   title,
+		Message: message,
+		Period:  period.String(),
+		Expire:  tmpl.Expire,
+		Urgency: tmpl.Urgency,
+	})
+}
+
+func (p *Daemon) sendNotification(notification notify.Notification) {
+	if err := p.notifier.Notify(context.Background(), notification); err != nil {
+		p.logger.Warn("failed to send notification", "title", notification.Title, "error", err)
+		return
+	}
+
+	p.logger.Debug("notification sent", "title", notification.Title)
+}
+
+// cycle is the number of the work period a notification of the period
+// belongs to, counting the work periods completed since the daemon
+// started. A break belongs to the work period before it.
+func (p *Daemon) cycle(period protocol.Period) int {
+	if isBreak(period) {
+		return p.completedWorkSessions
+	}
+
+	return p.completedWorkSessions + 1
+}
+
+func renderTemplate(tmpl *template.Template, data any) (string, error) {
+	var sb strings.Builder
+
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error executing template %s: %w", tmpl.Name(), err)
+	}
+
+	return sb.String(), nil
+}
+
+// describeNotifier fills in the notifier settings of config-get, the first
+// thing to check when notifications do not show up.
+func describeNotifier(notifier notify.Notifier, config *protocol.Config) {
+	switch n := notifier.(type) {
+	case *notify.Exec:
+		config.Notifier = notify.BackendExec
+		config.NotifyCmds = n.Commands
+
+		if len(n.Commands) == 0 {
+			config.NotifyCmds = notify.DefaultCommands
+		}
+	case *notify.Command:
+		config.Notifier = "command"
+		config.NotifyCommand = n.Source
+	case *notify.DBus:
+		config.Notifier = notify.BackendDBus
+	case *notify.MacOS:
+		config.Notifier = notify.BackendMacOS
+	case *notify.Windows:
+		config.Notifier = notify.BackendWindows
+	case notify.Noop:
+		config.Notifier = notify.BackendNone
+	}
+}
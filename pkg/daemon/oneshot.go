@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thek4n/pomodoro/internal/config"
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// firstPeriod is the period toggle and restart start with.
+func (p *Daemon) firstPeriod() protocol.Period {
+	if p.restOnly {
+		return protocol.Rest
+	}
+
+	return protocol.Work
+}
+
+// beginFirstPeriod starts a new cycle, which is a single period in the
+// one-shot modes.
+func (p *Daemon) beginFirstPeriod() {
+	p.enterPeriod(p.firstPeriod())
+	p.currentOneShot = p.oneShot || p.restOnly
+}
+
+// finishOneShot ends a one-shot period instead of switching to the next one.
+func (p *Daemon) finishOneShot() {
+	finishedPeriod := p.currentPeriod
+
+	// Like in switchTimer, a skipped work period is not counted.
+	p.workCounted = finishedPeriod == protocol.Work && !p.skipped
+	if p.workCounted {
+		p.countCompletedWork()
+	}
+
+	p.setRemaining(0)
+	p.recordCurrentPeriod()
+	p.stopPeriod()
+
+	p.printTransition(finishedPeriod)
+	p.onStateChange()
+
+	nextPeriod := p.getReversedPeriod(finishedPeriod)
+	p.playSound(p.soundForPeriod(nextPeriod))
+	p.notifyPeriod(nextPeriod)
+}
+
+func (p *Daemon) startOneShotTimer(period protocol.Period, duration time.Duration) (protocol.Status, error) {
+	if period != protocol.Work && period != protocol.Rest {
+		return protocol.Status{}, errors.New("timer period must be work or rest")
+	}
+
+	if duration < config.MinPeriodDuration {
+		return protocol.Status{}, fmt.Errorf("timer duration must be at least %s", config.MinPeriodDuration)
+	}
+
+	p.recordCurrentPeriod()
+	p.beginPeriod(period)
+	p.setRemaining(duration)
+	p.currentPeriodDuration = duration
+	p.currentOneShot = true
+	p.onStateChange()
+
+	return p.status(), nil
+}
+
+func (p *Daemon) handleTimerRequest(request protocol.Request) (protocol.Status, error) {
+	duration, err := time.ParseDuration(request.Args["duration"])
+	if err != nil {
+		return protocol.Status{}, fmt.Errorf("invalid timer duration: %w", err)
+	}
+
+	period := protocol.Work
+
+	if name := request.Args["period"]; name != "" {
+		period, err = protocol.ParsePeriod(name)
+		if err != nil {
+			return protocol.Status{}, err
+		}
+	}
+
+	return p.startOneShotTimer(period, duration)
+}
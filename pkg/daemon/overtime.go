@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// overtime is how long the work period has been overrun while the timer
+// waits for the break to be acknowledged.
+func (p *Daemon) overtime() time.Duration {
+	if p.currentPeriod != protocol.Waiting || !isBreak(p.nextPeriod) {
+		return 0
+	}
+
+	return p.clock.Now().Sub(p.waitingSince).Round(p.tick)
+}
+
+// holdWorkEntry ends the work period but keeps its entry out of the
+// history until the timer stops waiting, so it can carry the overtime.
+func (p *Daemon) holdWorkEntry() {
+	if entry, ok := p.finishPeriodEntry(); ok {
+		p.overtimeEntry = &entry
+	}
+}
+
+func (p *Daemon) recordOvertimeEntry() {
+	if p.overtimeEntry == nil {
+		return
+	}
+
+	entry := *p.overtimeEntry
+	entry.Overtime = p.clock.Now().Sub(p.waitingSince).Round(p.tick)
+	p.overtimeEntry = nil
+
+	p.addHistory(entry)
+}
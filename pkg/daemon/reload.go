@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// reload re-reads the configuration and applies what can change while the
+// daemon runs to it and its named timers: durations, the cycle, snoozes,
+// notifications, sounds and hooks. The running period keeps its countdown,
+// settings that need a new listener or connection keep their values until
+// a restart.
+func (p *Daemon) reload() protocol.Response {
+	if p.reloadConfig == nil {
+		return protocol.Response{Error: "reload is not supported"}
+	}
+
+	cfg, err := p.reloadConfig()
+	if err != nil {
+		p.logger.Warn("failed to reload config", "error", err)
+		return protocol.Response{Error: "failed to reload config: " + err.Error()}
+	}
+
+	var response protocol.Response
+
+	err = p.do(func() {
+		p.applyConfig(cfg)
+
+		config := p.config()
+		response.Config = &config
+		response.Message = "Config reloaded"
+	})
+	if err != nil {
+		return protocol.Response{Error: err.Error()}
+	}
+
+	// Timers that were added or removed need a restart.
+	for _, timer := range cfg.Timers {
+		if t, ok := p.timers[timer.Name]; ok {
+			timerConfig := p.timerConfig(cfg, timer)
+			t.do(func() { t.applyConfig(timerConfig) })
+		}
+	}
+
+	p.logger.Info("config reloaded")
+
+	return response
+}
+
+func (p *Daemon) applyConfig(cfg Config) {
+	p.initialPeriodDurations[protocol.Work] = cfg.WorkDuration
+	p.initialPeriodDurations[protocol.Rest] = cfg.RestDuration
+	p.initialPeriodDurations[protocol.LongRest] = cfg.LongRestDuration
+	p.initialPeriodDurations[protocol.Prepare] = cfg.Prepare
+
+	p.workStep = cfg.WorkStep
+	p.goal = cfg.Goal
+	p.verbose = cfg.Verbose
+	p.longBreakInterval = cfg.LongBreakInterval
+	p.snoozeDuration = cfg.SnoozeDuration
+	p.maxSnooze = cfg.MaxSnooze
+	p.manualSwitch = cfg.ManualSwitch
+	p.afterRest = cfg.AfterRest
+	p.afterWork = cfg.AfterWork
+	p.waitReminder = cfg.WaitReminder
+	p.onSuspend = cfg.OnSuspend
+	p.workSound = cfg.WorkSound
+	p.restSound = cfg.RestSound
+	p.soundPlayer = cfg.SoundPlayer
+	p.hooksDir = cfg.HooksDir
+	p.notifications = cfg.Notifications
+
+	if cfg.Catalog != nil {
+		p.catalog = cfg.Catalog
+	}
+	p.warnings = cfg.Warnings
+	p.warningTemplate = cfg.WarningNotification
+	p.strictBreaks = cfg.StrictBreaks
+
+	if cfg.BreakLockCmd != p.breakLockCmd {
+		p.endBreakLock()
+		p.breakLockCmd = cfg.BreakLockCmd
+		p.updateBreakLock()
+	}
+
+	p.onConfigChange()
+}
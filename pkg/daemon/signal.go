@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReloadOnSignal reloads the config on SIGHUP.
+func (p *Daemon) ReloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			p.logger.Info("reloading config", "signal", syscall.SIGHUP.String())
+			p.reload()
+		}
+	}()
+}
+
+// ShutdownOnSignal stops the daemon on SIGINT or SIGTERM, so the socket is
+// removed and the state saved instead of the process dying mid-write.
+func (p *Daemon) ShutdownOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+
+		p.logger.Info("shutting down", "signal", sig.String())
+		p.Shutdown()
+	}()
+}
@@ -0,0 +1,28 @@
+package daemon
+
+import (
+	"errors"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// skipTimer ends the current period right away as if its countdown had run
+// out, so its notification and sound fire as usual.
+func (p *Daemon) skipTimer() (protocol.Status, error) {
+	switch p.currentPeriod {
+	case protocol.Stopped:
+		return protocol.Status{}, errors.New("timer is stopped")
+	case protocol.Waiting:
+		p.enterPeriod(p.nextPeriod)
+		p.onStateChange()
+
+		return p.status(), nil
+	}
+
+	// Only the elapsed part ends up in the history.
+	p.currentPeriodDuration -= p.remaining()
+	p.skipped = true
+	p.switchTimer()
+
+	return p.status(), nil
+}
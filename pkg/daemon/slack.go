@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	slackAPI       = "https://slack.com/api/"
+	slackTimeout   = 10 * time.Second
+	slackQueueSize = 16
+)
+
+// slackUpdate sets the status and DND snooze until the given time, or
+// clears them when it is zero.
+type slackUpdate struct {
+	until time.Time
+}
+
+// slackIntegration sets the user's Slack status and snoozes notifications
+// while a work period runs.
+type slackIntegration struct {
+	token    string
+	text     string
+	emoji    string
+	client   *http.Client
+	logger   *slog.Logger
+	updates  chan slackUpdate
+	focusing bool
+}
+
+func newSlackIntegration(token, text, emoji string, logger *slog.Logger) *slackIntegration {
+	return &slackIntegration{
+		token:   token,
+		text:    text,
+		emoji:   emoji,
+		client:  &http.Client{Timeout: slackTimeout},
+		logger:  logger,
+		updates: make(chan slackUpdate, slackQueueSize),
+	}
+}
+
+// updateSlack focuses when a work period starts and clears the status when
+// it ends, pausing within the period leaves it as is.
+func (p *Daemon) updateSlack() {
+	if p.slack == nil {
+		return
+	}
+
+	focusing := p.currentPeriod == protocol.Work
+	if focusing == p.slack.focusing {
+		return
+	}
+
+	p.slack.focusing = focusing
+
+	var update slackUpdate
+	if focusing {
+		update.until = p.clock.Now().Add(p.remaining())
+	}
+
+	select {
+	case p.slack.updates <- update:
+	default:
+		p.logger.Warn("slack queue is full, dropping update")
+	}
+}
+
+// run applies the queued updates until ctx is done. A status it set is
+// cleared before it returns, so stopping the daemon mid-work does not leave
+// the user in DND.
+func (s *slackIntegration) run(ctx context.Context) {
+	var focused bool
+
+	for {
+		select {
+		case update := <-s.updates:
+			err := s.apply(ctx, update)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Warn("failed to update slack", "error", err)
+			}
+
+			// A failed focus may have set the status half way, a failed
+			// clear leaves it set.
+			focused = !update.until.IsZero() || (err != nil && focused)
+		case <-ctx.Done():
+			if !focused {
+				return
+			}
+
+			// ctx is done, the client timeout bounds the last update.
+			if err := s.apply(context.Background(), slackUpdate{}); err != nil {
+				s.logger.Warn("failed to clear slack status", "error", err)
+			}
+
+			return
+		}
+	}
+}
+
+func (s *slackIntegration) apply(ctx context.Context, update slackUpdate) error {
+	profile := map[string]any{"status_text": "", "status_emoji": "", "status_expiration": 0}
+
+	if !update.until.IsZero() {
+		profile = map[string]any{
+			"status_text":       s.text,
+			"status_emoji":      s.emoji,
+			"status_expiration": update.until.Unix(),
+		}
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := s.call(ctx, "users.profile.set", url.Values{"profile": {string(data)}}); err != nil {
+		return err
+	}
+
+	if update.until.IsZero() {
+		err := s.call(ctx, "dnd.endSnooze", nil)
+		if err != nil && !strings.Contains(err.Error(), "snooze_not_active") {
+			return err
+		}
+
+		return nil
+	}
+
+	minutes := int(math.Ceil(time.Until(update.until).Minutes()))
+
+	return s.call(ctx, "dnd.setSnooze", url.Values{"num_minutes": {strconv.Itoa(max(minutes, 1))}})
+}
+
+// call invokes a Slack Web API method, which reports failures in the body
+// rather than the status code.
+func (s *slackIntegration) call(ctx context.Context, method string, values url.Values) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, slackAPI+method, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+s.token)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer response.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", method, err)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("%s: %s", method, result.Error)
+	}
+
+	return nil
+}
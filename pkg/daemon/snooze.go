@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// snoozeWindow is how close to the end of a work period, or how soon after
+// the break started, a snooze is accepted.
+const snoozeWindow = 1 * time.Minute
+
+func (p *Daemon) snoozeTimer() (protocol.Status, error) {
+	if p.snoozeCount >= p.maxSnooze {
+		return protocol.Status{}, fmt.Errorf("snooze limit of %d reached", p.maxSnooze)
+	}
+
+	switch {
+	case p.currentPeriod == protocol.Work && p.remaining() <= snoozeWindow:
+		p.setRemaining(p.remaining() + p.snoozeDuration)
+		p.currentPeriodDuration += p.snoozeDuration
+	case isBreak(p.currentPeriod) && p.clock.Now().Sub(p.periodStartedAt) <= snoozeWindow,
+		p.currentPeriod == protocol.Waiting && isBreak(p.nextPeriod):
+		// The work period is continued, so it must not be counted twice
+		// when the snoozed part ends. A skipped one was not counted.
+		if p.workCounted {
+			p.uncountCompletedWork()
+			p.skipContinuedWork()
+		}
+
+		p.recordCurrentPeriod()
+		p.beginPeriod(protocol.Work)
+		p.setRemaining(p.snoozeDuration)
+		p.currentPeriodDuration = p.snoozeDuration
+	default:
+		return protocol.Status{}, errors.New("snooze is only possible around the end of a work period")
+	}
+
+	p.snoozeCount++
+	p.onStateChange()
+
+	return p.status(), nil
+}
@@ -0,0 +1,152 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+var defaultSoundPlayers = []string{"paplay", "aplay", "afplay"}
+
+// builtinSound selects the generated beep instead of an audio file.
+const builtinSound = "beep"
+
+func resolveSoundPlayer(player string) string {
+	if player != "" {
+		return player
+	}
+
+	for _, candidate := range defaultSoundPlayers {
+		if _, err := exec.LookPath(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	return defaultSoundPlayers[0]
+}
+
+func (p *Daemon) soundForPeriod(period protocol.Period) string {
+	switch period {
+	case protocol.Work:
+		return p.workSound
+	case protocol.Rest, protocol.LongRest:
+		return p.restSound
+	default:
+		return ""
+	}
+}
+
+func (p *Daemon) playSound(file string) {
+	if file == "" {
+		return
+	}
+
+	player := p.soundPlayer
+
+	go func() {
+		if file == builtinSound {
+			beep, err := writeBeep()
+			if err != nil {
+				p.logger.Warn("failed to write beep", "error", err)
+				return
+			}
+			defer os.Remove(beep)
+
+			file = beep
+		}
+
+		cmd := exec.Command(resolveSoundPlayer(player), file)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			p.logger.Warn("failed to play sound",
+				"file", file,
+				"player", cmd.Path,
+				"error", err,
+				"output", string(output),
+			)
+		}
+	}()
+}
+
+// writeBeep writes the built-in beep to a temporary WAV file, since the
+// players only take file names.
+func writeBeep() (string, error) {
+	f, err := os.CreateTemp("", "pomodoro-beep-*.wav")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Write(beepWAV()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+
+		return "", fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// beepWAV renders two short 880 Hz tones as 8-bit mono PCM.
+func beepWAV() []byte {
+	const (
+		sampleRate = 8000
+		frequency  = 880
+		tone       = sampleRate / 5
+		gap        = sampleRate / 10
+	)
+
+	samples := make([]byte, 0, 2*tone+gap)
+	for i := range 2*tone + gap {
+		if i >= tone && i < tone+gap {
+			samples = append(samples, 128)
+			continue
+		}
+
+		samples = append(samples, byte(128+64*math.Sin(2*math.Pi*frequency*float64(i)/sampleRate)))
+	}
+
+	header := struct {
+		Riff          [4]byte
+		Size          uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		Riff:          [4]byte{'R', 'I', 'F', 'F'},
+		Size:          uint32(36 + len(samples)),
+		Wave:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		Format:        1,
+		Channels:      1,
+		SampleRate:    sampleRate,
+		ByteRate:      sampleRate,
+		BlockAlign:    1,
+		BitsPerSample: 8,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      uint32(len(samples)),
+	}
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, header)
+	buf.Write(samples)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// daemonState is what the state file keeps to resume a session after a
+// restart.
+type daemonState struct {
+	Period                protocol.Period `json:"period"`
+	NextPeriod            protocol.Period `json:"next_period"`
+	Remaining             time.Duration   `json:"remaining"`
+	PeriodDuration        time.Duration   `json:"period_duration"`
+	Paused                bool            `json:"paused"`
+	OneShot               bool            `json:"one_shot"`
+	CompletedWorkSessions int             `json:"completed_work_sessions"`
+	CompletedToday        int             `json:"completed_today"`
+	CounterDay            string          `json:"counter_day"`
+	CycleSessions         int             `json:"cycle_sessions"`
+	Tag                   string          `json:"tag,omitempty"`
+}
+
+// writeStateFile saves the state on every transition and at most
+// once a minute while the timer counts down, which bounds what a crash can
+// lose without writing the file on every tick.
+func (p *Daemon) writeStateFile(transition bool) {
+	if p.stateFile == "" {
+		return
+	}
+
+	remaining := p.remaining()
+
+	if !transition && remaining%time.Minute >= p.tick {
+		return
+	}
+
+	data, err := json.Marshal(daemonState{
+		Period:                p.currentPeriod,
+		NextPeriod:            p.nextPeriod,
+		Remaining:             remaining,
+		PeriodDuration:        p.currentPeriodDuration,
+		Paused:                p.paused,
+		OneShot:               p.currentOneShot,
+		CompletedWorkSessions: p.completedWorkSessions,
+		CompletedToday:        p.completedToday,
+		CounterDay:            p.counterDay,
+		CycleSessions:         p.cycleSessions,
+		Tag:                   p.tag,
+	})
+	if err != nil {
+		p.logger.Error("failed to encode state", "error", err)
+		return
+	}
+
+	if bytes.Equal(data, p.lastStateFile) {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.stateFile), 0o755); err != nil {
+		p.logger.Warn("failed to write state file", "file", p.stateFile, "error", err)
+		return
+	}
+
+	if err := writeFileAtomic(p.stateFile, data); err != nil {
+		p.logger.Warn("failed to write state file", "file", p.stateFile, "error", err)
+		return
+	}
+
+	p.lastStateFile = data
+}
+
+// restoreStateFile resumes from the state file, reporting whether
+// there was a state to resume.
+func (p *Daemon) restoreStateFile() bool {
+	if p.stateFile == "" {
+		return false
+	}
+
+	data, err := os.ReadFile(p.stateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		p.logger.Warn("failed to read state file", "file", p.stateFile, "error", err)
+		return false
+	}
+
+	var state daemonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		p.logger.Warn("invalid state file", "file", p.stateFile, "error", err)
+		return false
+	}
+
+	switch state.Period {
+	case protocol.Work, protocol.Rest, protocol.LongRest, protocol.Prepare, protocol.Waiting, protocol.Stopped:
+	default:
+		p.logger.Warn("invalid state file", "file", p.stateFile, "period", state.Period)
+		return false
+	}
+
+	p.currentPeriod = state.Period
+	p.nextPeriod = state.NextPeriod
+	p.waitingSince = p.clock.Now()
+	p.setRemaining(state.Remaining)
+	p.currentPeriodDuration = state.PeriodDuration
+	p.periodStartedAt = p.clock.Now().Add(state.Remaining - state.PeriodDuration)
+	p.paused = state.Paused
+	p.currentOneShot = state.OneShot
+	p.completedWorkSessions = state.CompletedWorkSessions
+	p.cycleSessions = state.CycleSessions
+	p.tag = state.Tag
+
+	if state.CounterDay == p.counterDay {
+		p.completedToday = state.CompletedToday
+	}
+
+	p.logger.Info("state restored", "file", p.stateFile, "period", state.Period.String(), "remaining", state.Remaining)
+
+	return true
+}
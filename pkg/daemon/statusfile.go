@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const (
+	statusFormatText = "text"
+	statusFormatJSON = "json"
+)
+
+// writeStatusFile mirrors the status into the status file, skipping
+// the write when the content did not change.
+func (p *Daemon) writeStatusFile() {
+	if p.statusFile == "" {
+		return
+	}
+
+	status := p.status()
+
+	var data []byte
+
+	if p.statusFormat == statusFormatJSON {
+		encoded, err := json.Marshal(status)
+		if err != nil {
+			p.logger.Error("failed to encode status", "error", err)
+			return
+		}
+
+		data = encoded
+	} else {
+		data = []byte(status.String())
+	}
+
+	data = append(data, '\n')
+
+	if bytes.Equal(data, p.lastStatusFile) {
+		return
+	}
+
+	if err := writeFileAtomic(p.statusFile, data); err != nil {
+		p.logger.Warn("failed to write status file", "file", p.statusFile, "error", err)
+		return
+	}
+
+	p.lastStatusFile = data
+}
+
+// writeFileAtomic replaces the file through a rename so readers never see
+// partial content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"time"
+)
+
+// What the daemon does with a running period when the machine sleeps.
+const (
+	onSuspendCount = "count"
+	onSuspendPause = "pause"
+)
+
+// suspendThreshold is how far the wall clock has to run ahead of the
+// monotonic clock between two ticks before it is taken as a suspend.
+const suspendThreshold = 5 * time.Second
+
+// checkSuspend detects a suspend from the wall clock advancing during
+// the sleep while the monotonic clock the deadline is based on does not.
+// The sleep either counts toward the period or pauses it, and is noted in
+// the history entry of the period.
+func (p *Daemon) checkSuspend(now time.Time) {
+	last := p.lastTickAt
+	p.lastTickAt = now
+
+	if last.IsZero() || !p.isTicking() {
+		return
+	}
+
+	slept := now.Round(0).Sub(last.Round(0)) - now.Sub(last)
+	if clock, ok := p.clock.(sleepClock); ok {
+		slept = clock.Slept(last, now)
+	}
+
+	if slept < suspendThreshold {
+		return
+	}
+
+	p.logger.Info("resumed from suspend", "slept", slept.Round(time.Second), "policy", p.onSuspend)
+	p.suspendedFor += slept
+
+	if p.onSuspend == onSuspendPause {
+		p.setPaused(true)
+		p.onStateChange()
+		return
+	}
+
+	p.periodEndsAt = p.periodEndsAt.Add(-slept)
+}
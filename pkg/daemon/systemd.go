@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation.
+const listenFDsStart = 3
+
+// activatedListeners returns the sockets systemd passed to the daemon,
+// none when it was not socket activated. The environment is cleared so
+// child processes like hooks do not take the sockets for theirs.
+func activatedListeners() ([]net.Listener, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, count)
+
+	for fd := listenFDsStart; fd < listenFDsStart+count; fd++ {
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+
+		if err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+
+			return nil, fmt.Errorf("failed to use socket activation fd %d: %w", fd, err)
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// sdNotify sends a state like READY=1 to the service manager, it does
+// nothing when the daemon does not run as a Type=notify unit.
+func (p *Daemon) sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		p.logger.Warn("failed to notify systemd", "state", state, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		p.logger.Warn("failed to notify systemd", "state", state, "error", err)
+	}
+}
+
+// watchdogInterval is how often systemd expects a keep-alive, zero when
+// WatchdogSec is not set for the unit.
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pid, err := strconv.Atoi(os.Getenv("WATCHDOG_PID")); err == nil && pid != os.Getpid() {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// pingWatchdog keeps the watchdog happy for as long as the event loop
+// handles commands, so a stuck timer gets the daemon restarted.
+func (p *Daemon) pingWatchdog(interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopped:
+			return
+		case <-ticker.C:
+			if p.do(func() {}) == nil {
+				p.sdNotify("WATCHDOG=1")
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	taskTimeout   = 10 * time.Second
+	taskQueueSize = 16
+)
+
+// taskwarrior runs the task start and stop commands of the work periods
+// attached to a task, in order and off the event loop.
+type taskwarrior struct {
+	cmd      string
+	commands chan []string
+	done     chan struct{}
+}
+
+func newTaskwarrior(cmd string) *taskwarrior {
+	return &taskwarrior{
+		cmd:      cmd,
+		commands: make(chan []string, taskQueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *taskwarrior) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, t.cmd, append([]string{"rc.verbose=nothing", "rc.confirmation=off"}, args...)...)
+	cmd.WaitDelay = time.Second
+
+	return cmd
+}
+
+// resolve returns the UUID of the task with the given ID or UUID. IDs
+// change as tasks are completed, so only the UUID is kept.
+func (t *taskwarrior) resolve(ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+
+	cmd := t.command(ctx, "_get", ref+".uuid")
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to look up task %s: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+
+	uuid := strings.TrimSpace(string(output))
+	if uuid == "" {
+		return "", fmt.Errorf("no task %s", ref)
+	}
+
+	return uuid, nil
+}
+
+func (p *Daemon) runTaskCommands() {
+	defer close(p.taskwarrior.done)
+
+	for args := range p.taskwarrior.commands {
+		ctx, cancel := context.WithTimeout(context.Background(), taskTimeout)
+
+		if output, err := p.taskwarrior.command(ctx, args...).CombinedOutput(); err != nil {
+			p.logger.Warn("task command failed",
+				"args", strings.Join(args, " "),
+				"error", err,
+				"output", strings.TrimSpace(string(output)),
+			)
+		}
+
+		cancel()
+	}
+}
+
+// closeTaskCommands waits for the queued commands, so a task started by
+// the daemon is stopped before it exits.
+func (p *Daemon) closeTaskCommands() {
+	close(p.taskwarrior.commands)
+	<-p.taskwarrior.done
+}
+
+func (p *Daemon) queueTaskCommand(uuid, command string) {
+	select {
+	case p.taskwarrior.commands <- []string{uuid, command}:
+	default:
+		p.logger.Warn("task command queue is full, dropping command", "task", uuid, "command", command)
+	}
+}
+
+// updateTask starts the attached task when its work period begins, and
+// lets go of it if the period was left before.
+func (p *Daemon) updateTask() {
+	if p.task == "" || p.taskStarted {
+		return
+	}
+
+	switch p.currentPeriod {
+	case protocol.Work:
+		p.queueTaskCommand(p.task, "start")
+		p.taskStarted = true
+	case protocol.Prepare:
+	default:
+		p.task = ""
+	}
+}
+
+// finishTask stops the attached task as its work period ends and returns
+// its UUID for the history.
+func (p *Daemon) finishTask() string {
+	task := p.task
+	if task == "" || p.currentPeriod != protocol.Work {
+		return ""
+	}
+
+	if p.taskStarted {
+		p.queueTaskCommand(task, "stop")
+	}
+
+	p.task = ""
+	p.taskStarted = false
+
+	return task
+}
+
+// resolveTask replaces the task reference of a start request by its UUID
+// before the request reaches the event loop, task can be slow.
+func (p *Daemon) resolveTask(request *protocol.Request) error {
+	ref := request.Args["task"]
+	if ref == "" {
+		return nil
+	}
+
+	uuid, err := p.taskwarrior.resolve(ref)
+	if err != nil {
+		return err
+	}
+
+	request.Args = maps.Clone(request.Args)
+	request.Args["task"] = uuid
+
+	return nil
+}
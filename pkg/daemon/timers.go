@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// TimerConfig is a named timer with its own durations.
+type TimerConfig struct {
+	Name             string
+	WorkDuration     time.Duration
+	RestDuration     time.Duration
+	LongRestDuration time.Duration
+}
+
+// addTimer creates a named timer sharing the main timer's settings.
+func (p *Daemon) addTimer(cfg Config, timer TimerConfig) {
+	t := New(p.timerConfig(cfg, timer))
+	t.name = timer.Name
+	t.taskwarrior = p.taskwarrior
+
+	if p.timers == nil {
+		p.timers = make(map[string]*Daemon)
+	}
+
+	p.timers[timer.Name] = t
+}
+
+// timerConfig derives the configuration of a named timer from the main
+// one. Named timers only keep their state in memory, the files and
+// integrations stay with the main timer.
+func (p *Daemon) timerConfig(cfg Config, timer TimerConfig) Config {
+	cfg.WorkDuration = timer.WorkDuration
+	cfg.RestDuration = timer.RestDuration
+	cfg.LongRestDuration = timer.LongRestDuration
+	cfg.Logger = p.logger.With("timer", timer.Name)
+	cfg.Notifier = p.notifier
+	cfg.Clock = p.clock
+
+	cfg.Timers = nil
+	cfg.Reload = nil
+	cfg.HTTPAddress = ""
+	cfg.MetricsAddress = ""
+	cfg.DBus = false
+	cfg.GnomePomodoro = false
+	cfg.MQTTBroker = ""
+	cfg.Webhooks = nil
+	cfg.SlackToken = ""
+	cfg.BusyCheckCmd = ""
+	cfg.IdleCmd = ""
+	cfg.BreakLockCmd = ""
+	cfg.StatusFile = ""
+	cfg.HistoryFile = ""
+	cfg.StateFile = ""
+	cfg.Restore = false
+	cfg.StartPeriod = 0
+	cfg.StartRemaining = 0
+	cfg.NotifyStart = false
+
+	return cfg
+}
+
+// timer returns the named timer, the main one for an empty name.
+func (p *Daemon) timer(name string) (*Daemon, error) {
+	if name == "" {
+		return p, nil
+	}
+
+	timer, ok := p.timers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown timer %q", name)
+	}
+
+	return timer, nil
+}
+
+// timerStatuses lists the main timer followed by the named ones in
+// alphabetical order.
+func (p *Daemon) timerStatuses() []protocol.Status {
+	timers := []*Daemon{p}
+	for _, name := range slices.Sorted(maps.Keys(p.timers)) {
+		timers = append(timers, p.timers[name])
+	}
+
+	statuses := make([]protocol.Status, 0, len(timers))
+
+	for _, timer := range timers {
+		response := timer.runCommand(protocol.Request{Cmd: "get"})
+		if response.Status != nil {
+			statuses = append(statuses, *response.Status)
+		}
+	}
+
+	return statuses
+}
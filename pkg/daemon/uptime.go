@@ -0,0 +1,12 @@
+package daemon
+
+import (
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+func (p *Daemon) getUptime() protocol.Uptime {
+	return protocol.Uptime{
+		StartedAt: p.startedAt,
+		Duration:  p.clock.Now().Sub(p.startedAt),
+	}
+}
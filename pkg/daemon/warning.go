@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+// checkWarnings sends the heads-up notification of the warning points the
+// countdown went past since the last check. When several were passed at
+// once, like after a suspend, only the closest to the end is sent.
+func (p *Daemon) checkWarnings() {
+	remaining := p.remaining()
+	previous := p.warnedRemaining
+	p.warnedRemaining = remaining
+
+	warnings := p.warnings[p.currentPeriod]
+	if len(warnings) == 0 || remaining <= 0 {
+		return
+	}
+
+	passed := false
+
+	for _, warning := range warnings {
+		if remaining <= warning && warning < previous {
+			passed = true
+		}
+	}
+
+	if passed {
+		p.notifyWarning(remaining)
+	}
+}
+
+func (p *Daemon) notifyWarning(remaining time.Duration) {
+	if p.notificationsMuted(p.clock.Now()) {
+		p.logger.Debug("warning muted", "period", p.currentPeriod.String(), "until", p.mutedUntil)
+		return
+	}
+
+	// The heads-up is shown like the notification of the period it is for.
+	tmpl := p.warningTemplate
+	tmpl.Expire = p.notifications[p.currentPeriod].Expire
+	tmpl.Urgency = p.notifications[p.currentPeriod].Urgency
+
+	p.notify(tmpl, p.currentPeriod, notificationData{
+		Duration:       protocol.FormatShortDuration(p.currentPeriodDuration),
+		Remaining:      protocol.FormatShortDuration(remaining),
+		Cycle:          p.cycle(p.currentPeriod),
+		Tag:            p.tag,
+		Completed:      p.completedWorkSessions,
+		CompletedToday: p.completedToday,
+		Goal:           p.goal,
+	})
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const subscriberQueueSize = 8
+
+type statusEvent struct {
+	status     protocol.Status
+	transition bool
+}
+
+type subscriber struct {
+	granularity string
+	events      chan statusEvent
+}
+
+func (s *subscriber) wants(event statusEvent, last protocol.Status) bool {
+	return wants(s.granularity, event, last)
+}
+
+// wants reports whether the event is worth passing on at the granularity
+// given the last status passed on.
+func wants(granularity string, event statusEvent, last protocol.Status) bool {
+	switch granularity {
+	case protocol.GranularityTransition:
+		return event.transition
+	case protocol.GranularityMinute:
+		return event.transition || ceilMinutes(event.status.RestOfTime) != ceilMinutes(last.RestOfTime)
+	default:
+		return true
+	}
+}
+
+func ceilMinutes(d time.Duration) time.Duration {
+	return (d + time.Minute - 1) / time.Minute
+}
+
+// broadcast hands the current status to every watcher. Slow watchers miss
+// updates instead of blocking the event loop.
+func (p *Daemon) broadcast(transition bool) {
+	if len(p.subscribers) == 0 {
+		return
+	}
+
+	event := statusEvent{status: p.status(), transition: transition}
+
+	for s := range p.subscribers {
+		select {
+		case s.events <- event:
+		default:
+		}
+	}
+}
+
+func (p *Daemon) streamStatus(conn net.Conn, request protocol.Request) {
+	// The stream stays open until the client goes away, only the writes
+	// are bounded.
+	_ = conn.SetReadDeadline(time.Time{})
+
+	gone := make(chan struct{})
+
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+
+	err := p.stream(request.Args["granularity"], gone, func(status protocol.Status) error {
+		return p.writeResponse(conn, protocol.Response{Status: &status})
+	})
+	if err != nil {
+		p.writeResponse(conn, protocol.Response{Error: err.Error()})
+	}
+}
+
+// stream passes the current status and then every update matching the
+// granularity to send, until gone is closed, the daemon stops or send
+// fails. Only an unknown granularity is reported as an error.
+func (p *Daemon) stream(granularity string, gone <-chan struct{}, send func(protocol.Status) error) error {
+	if granularity == "" {
+		granularity = protocol.GranularitySecond
+	}
+
+	if granularity != protocol.GranularitySecond && granularity != protocol.GranularityMinute && granularity != protocol.GranularityTransition {
+		return fmt.Errorf("Unknown granularity %q", granularity)
+	}
+
+	sub := &subscriber{
+		granularity: granularity,
+		events:      make(chan statusEvent, subscriberQueueSize),
+	}
+
+	var last protocol.Status
+
+	if err := p.do(func() {
+		p.subscribers[sub] = struct{}{}
+		last = p.status()
+	}); err != nil {
+		return nil
+	}
+
+	defer p.do(func() { delete(p.subscribers, sub) })
+
+	if err := send(last); err != nil {
+		return nil
+	}
+
+	for {
+		select {
+		case <-p.stopped:
+			return nil
+		case <-gone:
+			return nil
+		case event := <-sub.events:
+			if !sub.wants(event, last) {
+				continue
+			}
+
+			if err := send(event.status); err != nil {
+				return nil
+			}
+
+			last = event.status
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/thek4n/pomodoro/pkg/protocol"
+)
+
+const (
+	webhookTimeout   = 10 * time.Second
+	webhookQueueSize = 16
+)
+
+// webhookEvent is the JSON body posted to the webhooks.
+type webhookEvent struct {
+	Period         string          `json:"period"`
+	PreviousPeriod string          `json:"previous_period"`
+	Timestamp      time.Time       `json:"timestamp"`
+	Cycle          int             `json:"cycle"`
+	Tag            string          `json:"tag,omitempty"`
+	Status         protocol.Status `json:"status"`
+}
+
+type webhookSender struct {
+	urls   []string
+	client *http.Client
+	logger *slog.Logger
+	events chan []byte
+	period protocol.Period
+}
+
+func newWebhookSender(urls []string, logger *slog.Logger) *webhookSender {
+	return &webhookSender{
+		urls:   urls,
+		client: &http.Client{Timeout: webhookTimeout},
+		logger: logger,
+		events: make(chan []byte, webhookQueueSize),
+		period: protocol.Stopped,
+	}
+}
+
+// send queues the event without blocking, dropping it if the webhooks
+// cannot keep up.
+func (w *webhookSender) send(body []byte) {
+	select {
+	case w.events <- body:
+	default:
+		w.logger.Warn("webhook queue is full, dropping event")
+	}
+}
+
+// run posts the queued events until ctx is done, events still queued then
+// are dropped.
+func (w *webhookSender) run(ctx context.Context) {
+	for {
+		var body []byte
+
+		select {
+		case <-ctx.Done():
+			return
+		case body = <-w.events:
+		}
+
+		for _, url := range w.urls {
+			if err := w.post(ctx, url, body); err != nil && ctx.Err() == nil {
+				w.logger.Warn("webhook failed", "url", url, "error", err)
+			}
+		}
+	}
+}
+
+func (w *webhookSender) post(ctx context.Context, url string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return nil
+}
+
+// callWebhooks posts the event of entering a new period, state changes
+// within a period like pausing are not sent.
+func (p *Daemon) callWebhooks() {
+	if p.webhooks == nil || p.currentPeriod == p.webhooks.period {
+		return
+	}
+
+	previous := p.webhooks.period
+	p.webhooks.period = p.currentPeriod
+
+	body, err := json.Marshal(webhookEvent{
+		Period:         p.currentPeriod.String(),
+		PreviousPeriod: previous.String(),
+		Timestamp:      p.clock.Now(),
+		Cycle:          p.completedWorkSessions,
+		Tag:            p.tag,
+		Status:         p.status(),
+	})
+	if err != nil {
+		p.logger.Error("failed to encode webhook event", "error", err)
+		return
+	}
+
+	p.webhooks.send(body)
+}
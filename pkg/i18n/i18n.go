@@ -0,0 +1,159 @@
+// Package i18n holds the translations of the notifications and of the
+// strings the client prints. Period names in the protocol stay the same in
+// every language, only their display names are translated.
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultLanguage is used when no catalog matches and for the messages a
+// catalog lacks.
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.toml
+var catalogs embed.FS
+
+// Catalog holds the messages of a language by section and key, like
+// period.Work.
+type Catalog struct {
+	lang     string
+	messages map[string]string
+	fallback *Catalog
+}
+
+// Load returns the catalog of the language, like ru or ru_RU.UTF-8.
+func Load(lang string) (*Catalog, error) {
+	lang = normalize(lang)
+
+	catalog, err := load(lang)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unsupported language %q, available: %s", lang, strings.Join(Languages(), ", "))
+	}
+
+	if err != nil || lang == DefaultLanguage {
+		return catalog, err
+	}
+
+	catalog.fallback, err = load(DefaultLanguage)
+
+	return catalog, err
+}
+
+// Detect returns the catalog of the language of the environment, from
+// LC_ALL, LC_MESSAGES or LANG, and English when there is none for it.
+func Detect() *Catalog {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if catalog, err := Load(value); err == nil {
+				return catalog
+			}
+
+			break
+		}
+	}
+
+	return English()
+}
+
+// English returns the default catalog.
+func English() *Catalog {
+	catalog, err := load(DefaultLanguage)
+	if err != nil {
+		panic(err)
+	}
+
+	return catalog
+}
+
+// Languages lists the languages there are catalogs for.
+func Languages() []string {
+	entries, _ := catalogs.ReadDir("catalogs")
+
+	languages := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".toml"))
+	}
+
+	return languages
+}
+
+func load(lang string) (*Catalog, error) {
+	data, err := catalogs.ReadFile("catalogs/" + lang + ".toml")
+	if err != nil {
+		return nil, err
+	}
+
+	var sections map[string]map[string]string
+	if err := toml.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("invalid %s catalog: %w", lang, err)
+	}
+
+	catalog := &Catalog{lang: lang, messages: make(map[string]string)}
+
+	for section, messages := range sections {
+		for key, message := range messages {
+			catalog.messages[section+"."+key] = message
+		}
+	}
+
+	return catalog, nil
+}
+
+// normalize turns locale names like ru_RU.UTF-8 into the language code.
+func normalize(lang string) string {
+	lang, _, _ = strings.Cut(lang, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	lang, _, _ = strings.Cut(lang, "_")
+	lang = strings.ToLower(lang)
+
+	if lang == "c" || lang == "posix" {
+		return DefaultLanguage
+	}
+
+	return lang
+}
+
+// Lang is the language code of the catalog.
+func (c *Catalog) Lang() string {
+	return c.lang
+}
+
+// Text returns the message of the key, the English one when the catalog
+// lacks it and the key itself when no catalog has it.
+func (c *Catalog) Text(key string) string {
+	if message, ok := c.lookup(key); ok {
+		return message
+	}
+
+	return key
+}
+
+// Period returns the display name of a period name of the protocol, the
+// name itself when it has none.
+func (c *Catalog) Period(name string) string {
+	if message, ok := c.lookup("period." + name); ok {
+		return message
+	}
+
+	return name
+}
+
+func (c *Catalog) lookup(key string) (string, bool) {
+	if message, ok := c.messages[key]; ok {
+		return message, true
+	}
+
+	if c.fallback != nil {
+		return c.fallback.lookup(key)
+	}
+
+	return "", false
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// commandData is available to the command template. The strings are
+// quoted for the shell, so they can be used as arguments as they are.
+type commandData struct {
+	Title   string
+	Message string
+	Period  string
+	Urgency string
+	Expire  int32
+}
+
+// Command runs a user supplied shell command for every notification, like
+// dunstify -u critical {{.Title}} {{.Message}}. The values are also in
+// the POMODORO_TITLE, POMODORO_MESSAGE, POMODORO_PERIOD and
+// POMODORO_URGENCY environment variables.
+type Command struct {
+	Template *template.Template
+	Timeout  time.Duration
+
+	// Source is the unparsed template, reported by config-get.
+	Source string
+}
+
+// NewCommand parses the command template.
+func NewCommand(command string) (*Command, error) {
+	tmpl, err := template.New("notify-command").Parse(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification command template: %w", err)
+	}
+
+	return &Command{Template: tmpl, Source: command}, nil
+}
+
+func (c *Command) Notify(ctx context.Context, notification Notification) error {
+	urgency := notification.Urgency
+	if urgency == "" {
+		urgency = UrgencyNormal
+	}
+
+	var sb strings.Builder
+
+	err := c.Template.Execute(&sb, commandData{
+		Title:   shellQuote(notification.Title),
+		Message: shellQuote(notification.Message),
+		Period:  shellQuote(notification.Period),
+		Urgency: urgency,
+		Expire:  notification.expireMillis(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render notification command: %w", err)
+	}
+
+	env := []string{
+		"POMODORO_TITLE=" + notification.Title,
+		"POMODORO_MESSAGE=" + notification.Message,
+		"POMODORO_PERIOD=" + notification.Period,
+		"POMODORO_URGENCY=" + urgency,
+		"POMODORO_EXPIRE=" + strconv.Itoa(int(notification.expireMillis())),
+	}
+
+	name, args := "sh", []string{"-c", sb.String()}
+	if runtime.GOOS == "windows" {
+		name, args = "powershell.exe", []string{"-NoProfile", "-NonInteractive", "-Command", sb.String()}
+	}
+
+	output, err := runCommandEnv(ctx, c.Timeout, env, name, args...)
+	if err != nil {
+		return fmt.Errorf("notification command: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// shellQuote wraps the value in single quotes, which sh and PowerShell
+// both take literally, escaping the quotes inside the way each expects.
+func shellQuote(value string) string {
+	if runtime.GOOS == "windows" {
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
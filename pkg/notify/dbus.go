@@ -0,0 +1,67 @@
+//go:build !freebsd
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusDestination = "org.freedesktop.Notifications"
+	dbusPath        = "/org/freedesktop/Notifications"
+	dbusMethod      = dbusDestination + ".Notify"
+)
+
+// DBus talks to the notification server of the session bus directly, the
+// same server libnotify and notify-send use.
+type DBus struct {
+	Timeout time.Duration
+}
+
+// dbusUrgency maps the urgency levels to the values of the urgency hint.
+var dbusUrgency = map[string]byte{
+	UrgencyLow:      0,
+	UrgencyNormal:   1,
+	UrgencyCritical: 2,
+}
+
+func (d *DBus) Notify(ctx context.Context, notification Notification) error {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := dbus.ConnectSessionBus(dbus.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+	defer conn.Close()
+
+	hints := map[string]dbus.Variant{}
+	if urgency, ok := dbusUrgency[notification.Urgency]; ok {
+		hints["urgency"] = dbus.MakeVariant(urgency)
+	}
+
+	call := conn.Object(dbusDestination, dbusPath).CallWithContext(ctx, dbusMethod, 0,
+		AppName,
+		uint32(0),
+		"",
+		notification.Title,
+		notification.Message,
+		[]string{},
+		hints,
+		notification.expireMillis(),
+	)
+	if call.Err != nil {
+		return fmt.Errorf("failed to send notification over D-Bus: %w", call.Err)
+	}
+
+	return nil
+}
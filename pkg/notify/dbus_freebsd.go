@@ -0,0 +1,16 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DBus is not supported on FreeBSD, which godbus does not build on.
+type DBus struct {
+	Timeout time.Duration
+}
+
+func (d *DBus) Notify(ctx context.Context, notification Notification) error {
+	return errors.New("the dbus notifier is not supported on FreeBSD")
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// DefaultCommands are tried when Exec has no commands configured.
+var DefaultCommands = []string{"notify-send"}
+
+// DefaultTimeout bounds a notification command so a wedged notification
+// daemon cannot pile up hanging processes.
+const DefaultTimeout = 5 * time.Second
+
+// Exec runs notify-send compatible commands, trying them in order until one
+// succeeds.
+type Exec struct {
+	Commands []string
+	Timeout  time.Duration
+	Logger   *slog.Logger
+}
+
+func (e *Exec) Notify(ctx context.Context, notification Notification) error {
+	commands := e.Commands
+	if len(commands) == 0 {
+		commands = DefaultCommands
+	}
+
+	logger := e.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	args := []string{"-t", strconv.Itoa(int(notification.expireMillis())), "-a", AppName}
+	if notification.Urgency != "" {
+		args = append(args, "-u", notification.Urgency)
+	}
+
+	args = append(args, notification.Title, notification.Message)
+
+	var errs []error
+
+	for _, command := range commands {
+		if _, err := exec.LookPath(command); err != nil {
+			logger.Debug("notification command not found", "command", command)
+			errs = append(errs, err)
+
+			continue
+		}
+
+		output, err := runCommand(ctx, e.Timeout, command, args...)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			logger.Warn("notification command timed out", "command", command)
+		} else {
+			logger.Debug("notification command failed",
+				"command", command,
+				"error", err,
+				"output", string(output),
+			)
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", command, err))
+	}
+
+	return fmt.Errorf("no notification command succeeded: %w", errors.Join(errs...))
+}
+
+// runCommand runs a notification command, killing it after the timeout
+// (DefaultTimeout when zero). A timeout is reported as
+// context.DeadlineExceeded.
+func runCommand(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	return runCommandEnv(ctx, timeout, nil, name, args...)
+}
+
+// runCommandEnv is runCommand with extra environment variables.
+func runCommandEnv(ctx context.Context, timeout time.Duration, env []string, name string, args ...string) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = time.Second
+
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil && ctx.Err() != nil {
+		return output, ctx.Err()
+	}
+
+	return output, err
+}
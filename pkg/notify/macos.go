@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// displayNotificationScript takes the title and the message as arguments so
+// neither needs AppleScript quoting.
+const displayNotificationScript = `on run argv
+display notification (item 2 of argv) with title (item 1 of argv)
+end run`
+
+// MacOS shows notifications through terminal-notifier when it is installed
+// and through osascript otherwise.
+type MacOS struct {
+	Timeout time.Duration
+}
+
+func (m *MacOS) Notify(ctx context.Context, notification Notification) error {
+	title, message := notification.Title, notification.Message
+
+	name := "osascript"
+	args := []string{"-e", displayNotificationScript, title, message}
+
+	if _, err := exec.LookPath("terminal-notifier"); err == nil {
+		name = "terminal-notifier"
+		args = []string{"-title", title, "-message", message, "-group", AppName}
+	}
+
+	output, err := runCommand(ctx, m.Timeout, name, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Package notify delivers desktop notifications through interchangeable
+// backends.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// AppName is the application name notifications are sent with.
+const AppName = "Pomodoro Timer"
+
+// Notifier shows a notification.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Notification is a title and a message, with hints on how to show them
+// that not every backend supports.
+type Notification struct {
+	Title   string
+	Message string
+
+	// Period is the period the notification announces, empty for the
+	// daemon's own notifications.
+	Period string
+
+	// Expire is how long the notification stays on screen, DefaultExpire
+	// when zero and until dismissed when NeverExpire.
+	Expire time.Duration
+
+	// Urgency is low, normal or critical, normal when empty.
+	Urgency string
+}
+
+const (
+	DefaultExpire = 5 * time.Second
+	NeverExpire   = time.Duration(-1)
+)
+
+// Urgency levels of a notification.
+const (
+	UrgencyLow      = "low"
+	UrgencyNormal   = "normal"
+	UrgencyCritical = "critical"
+)
+
+// expireMillis is the expire timeout in the milliseconds notify-send and
+// the notification server take, zero meaning never.
+func (n Notification) expireMillis() int32 {
+	switch {
+	case n.Expire < 0:
+		return 0
+	case n.Expire == 0:
+		return int32(DefaultExpire.Milliseconds())
+	default:
+		return int32(n.Expire.Milliseconds())
+	}
+}
+
+// Backend names accepted by New.
+const (
+	BackendAuto    = "auto"
+	BackendExec    = "exec"
+	BackendDBus    = "dbus"
+	BackendMacOS   = "macos"
+	BackendWindows = "windows"
+	BackendNone    = "none"
+)
+
+// New returns the notifier of the named backend. The commands are only used
+// by the exec backend. The auto backend picks macos on darwin, windows on
+// Windows and exec everywhere else.
+func New(backend string, commands []string, logger *slog.Logger) (Notifier, error) {
+	if backend == BackendAuto || backend == "" {
+		switch runtime.GOOS {
+		case "darwin":
+			backend = BackendMacOS
+		case "windows":
+			backend = BackendWindows
+		default:
+			backend = BackendExec
+		}
+	}
+
+	switch backend {
+	case BackendExec:
+		return &Exec{Commands: commands, Logger: logger}, nil
+	case BackendDBus:
+		return &DBus{}, nil
+	case BackendMacOS:
+		return &MacOS{}, nil
+	case BackendWindows:
+		return &Windows{}, nil
+	case BackendNone:
+		return Noop{}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification backend %q", backend)
+	}
+}
+
+// Noop drops every notification.
+type Noop struct{}
+
+func (Noop) Notify(context.Context, Notification) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// toastScript shows a toast through the WinRT notification API. The title
+// and the message come from environment variables so neither needs
+// PowerShell quoting.
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:POMODORO_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:POMODORO_MESSAGE)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:POMODORO_APP).Show($toast)
+`
+
+// Windows shows toast notifications through PowerShell.
+type Windows struct {
+	Timeout time.Duration
+}
+
+func (w *Windows) Notify(ctx context.Context, notification Notification) error {
+	env := []string{
+		"POMODORO_TITLE=" + notification.Title,
+		"POMODORO_MESSAGE=" + notification.Message,
+		"POMODORO_APP=" + AppName,
+	}
+
+	output, err := runCommandEnv(ctx, w.Timeout, env, "powershell.exe",
+		"-NoProfile", "-NonInteractive", "-Command", toastScript)
+	if err != nil {
+		return fmt.Errorf("powershell.exe: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package protocol
+
+import (
+	"errors"
+	"net"
+)
+
+var errPipeUnsupported = errors.New("named pipes are only supported on Windows")
+
+type pipeTransport struct{}
+
+func (pipeTransport) Listen(string) (net.Listener, error) {
+	return nil, errPipeUnsupported
+}
+
+func (pipeTransport) Dial(string) (net.Conn, error) {
+	return nil, errPipeUnsupported
+}
@@ -0,0 +1,26 @@
+package protocol
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// pipeSecurity restricts the named pipe to its owner.
+const pipeSecurity = "D:P(A;;GA;;;OW)"
+
+type pipeTransport struct{}
+
+func (pipeTransport) Listen(address string) (net.Listener, error) {
+	listener, err := winio.ListenPipe(address, &winio.PipeConfig{SecurityDescriptor: pipeSecurity})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	return listener, nil
+}
+
+func (pipeTransport) Dial(address string) (net.Conn, error) {
+	return winio.DialPipe(address, nil)
+}
@@ -0,0 +1,123 @@
+// Package protocol defines the messages exchanged with the pomodoro daemon
+// and the transports carrying them.
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Messages on the socket are JSON documents terminated by a newline.
+//
+// A request is an envelope {"command": "get", "version": 1, "args": {...}},
+// where "cmd" is still accepted for "command" and version may be omitted.
+// A bare command name such as "get" or "switch" is accepted as well, for
+// clients predating the envelope.
+//
+// A response {"version": 1, "status": ..., "error": ...} carries the
+// protocol version of the daemon and only the fields relevant to the
+// command. A non-empty error means the command failed.
+const (
+	messageDelimiter = '\n'
+	maxRequestSize   = 64 * 1024
+	readChunkSize    = 1024
+)
+
+// Version is bumped on incompatible changes of the messages.
+const Version = 1
+
+// WriteResponse writes the response stamped with the protocol version.
+func WriteResponse(w io.Writer, response Response) error {
+	response.Version = Version
+
+	return WriteMessage(w, response)
+}
+
+func WriteMessage(w io.Writer, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error encoding message: %w", err)
+	}
+
+	data = append(data, messageDelimiter)
+
+	for len(data) > 0 {
+		n, err := w.Write(data)
+		if err != nil {
+			return fmt.Errorf("error writing message: %w", err)
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
+
+// ReadMessage decodes the next newline-terminated message, io.EOF is
+// returned as is when the peer closed the connection between messages.
+func ReadMessage(r *bufio.Reader, message any) error {
+	line, err := r.ReadBytes(messageDelimiter)
+	if err != nil && (!errors.Is(err, io.EOF) || len(bytes.TrimSpace(line)) == 0) {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+
+		return fmt.Errorf("error reading message: %w", err)
+	}
+
+	if err := json.Unmarshal(line, message); err != nil {
+		return fmt.Errorf("error parsing message: %w", err)
+	}
+
+	return nil
+}
+
+// ReadRequest reads a single request. Besides newline-terminated messages
+// it accepts legacy clients that send a bare command or a JSON document
+// without the delimiter and then wait for the response.
+func ReadRequest(r io.Reader) ([]byte, error) {
+	data := make([]byte, 0, readChunkSize)
+	chunk := make([]byte, readChunkSize)
+
+	for {
+		n, err := r.Read(chunk)
+		data = append(data, chunk[:n]...)
+
+		if i := bytes.IndexByte(data, messageDelimiter); i >= 0 {
+			return data[:i], nil
+		}
+
+		if isCompleteRequest(data) {
+			return data, nil
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(data) > 0 {
+				return data, nil
+			}
+
+			return nil, fmt.Errorf("error reading request: %w", err)
+		}
+
+		if len(data) > maxRequestSize {
+			return nil, fmt.Errorf("request exceeds %d bytes", maxRequestSize)
+		}
+	}
+}
+
+func isCompleteRequest(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return false
+	}
+
+	if trimmed[0] != '{' {
+		return true
+	}
+
+	return json.Valid(trimmed)
+}
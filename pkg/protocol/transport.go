@@ -0,0 +1,176 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Transport creates the daemon's listener and the client's connections, so
+// the command handlers do not depend on unix sockets directly.
+type Transport interface {
+	Listen(address string) (net.Listener, error)
+	Dial(address string) (net.Conn, error)
+}
+
+const (
+	tcpAddressPrefix  = "tcp://"
+	pipeAddressPrefix = `\\.\pipe\`
+)
+
+// AddressTransport picks the transport from the form of the address:
+// tcp://host:port for localhost TCP, \\.\pipe\name for a Windows named pipe
+// and a unix socket path otherwise.
+type AddressTransport struct{}
+
+func (AddressTransport) resolve(address string) (Transport, string) {
+	switch {
+	case strings.HasPrefix(address, tcpAddressPrefix):
+		return tcpTransport{}, strings.TrimPrefix(address, tcpAddressPrefix)
+	case strings.HasPrefix(address, pipeAddressPrefix):
+		return pipeTransport{}, address
+	default:
+		return unixTransport{}, address
+	}
+}
+
+func (t AddressTransport) Listen(address string) (net.Listener, error) {
+	transport, address := t.resolve(address)
+	return transport.Listen(address)
+}
+
+func (t AddressTransport) Dial(address string) (net.Conn, error) {
+	transport, address := t.resolve(address)
+	return transport.Dial(address)
+}
+
+// IsSocketFile reports whether the address names a unix socket file that has
+// to be removed once the daemon stops.
+func IsSocketFile(address string) bool {
+	transport, _ := AddressTransport{}.resolve(address)
+	_, ok := transport.(unixTransport)
+
+	return ok
+}
+
+type unixTransport struct{}
+
+func (unixTransport) Listen(address string) (net.Listener, error) {
+	if err := removeStaleSocket(address); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket: %w", err)
+	}
+
+	return listener, nil
+}
+
+func (unixTransport) Dial(address string) (net.Conn, error) {
+	return net.Dial("unix", address)
+}
+
+// removeStaleSocket removes a socket file left behind by a daemon that did
+// not exit cleanly. A socket that still accepts connections belongs to a
+// running daemon and is kept.
+func removeStaleSocket(address string) error {
+	info, err := os.Lstat(address)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s already exists and is not a socket", address)
+	}
+
+	conn, err := net.Dial("unix", address)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("another daemon is already listening on %s", address)
+	}
+
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("socket %s already exists: %w", address, err)
+	}
+
+	if err := os.Remove(address); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	return nil
+}
+
+// IsLocal reports whether the connection came over a unix socket or a
+// named pipe, which only the user's own processes can reach. Loopback TCP
+// is open to every user on the machine and does not count.
+func IsLocal(conn net.Conn) bool {
+	network := conn.LocalAddr().Network()
+
+	return network == "unix" || network == "pipe"
+}
+
+// tcpTransport is the fallback for platforms without unix sockets or named
+// pipes. It only accepts loopback addresses since the protocol is not
+// encrypted.
+type tcpTransport struct{}
+
+func (tcpTransport) Listen(address string) (net.Listener, error) {
+	if err := checkLoopback(address); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	return listener, nil
+}
+
+func (tcpTransport) Dial(address string) (net.Conn, error) {
+	return net.Dial("tcp", address)
+}
+
+// ListenTCP listens on a tcp://host:port address on any interface, unlike a
+// tcp:// socket address which is limited to loopback. Anyone who can reach
+// it can talk to the daemon, so it is meant to be guarded by an auth token.
+func ListenTCP(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, tcpAddressPrefix) {
+		return nil, fmt.Errorf("listen address %q must start with %s", address, tcpAddressPrefix)
+	}
+
+	address = strings.TrimPrefix(address, tcpAddressPrefix)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	return listener, nil
+}
+
+func checkLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid tcp address %q: %w", address, err)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("tcp address %q is not a loopback address", address)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package protocol
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemoveStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.sock")
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing a unix listener removes its file, keep it like a daemon that
+	// was killed would.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	if err := removeStaleSocket(path); err != nil {
+		t.Fatalf("removeStaleSocket() = %v, want nil", err)
+	}
+
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("stale socket still exists: %v", err)
+	}
+}
+
+func TestRemoveStaleSocketKeepsListeningSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.sock")
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	err = removeStaleSocket(path)
+	if err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Fatalf("removeStaleSocket() = %v, want already listening error", err)
+	}
+
+	if _, err := os.Lstat(path); err != nil {
+		t.Fatalf("socket of a running daemon was removed: %v", err)
+	}
+}
+
+func TestRemoveStaleSocketKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.sock")
+
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := removeStaleSocket(path)
+	if err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Fatalf("removeStaleSocket() = %v, want not a socket error", err)
+	}
+}
+
+func TestRemoveStaleSocketMissing(t *testing.T) {
+	if err := removeStaleSocket(filepath.Join(t.TempDir(), "p.sock")); err != nil {
+		t.Fatalf("removeStaleSocket() = %v, want nil", err)
+	}
+}
+
+func TestUnixTransportReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.sock")
+
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := AddressTransport{}.Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() = %v, want nil", err)
+	}
+	defer listener.Close()
+
+	conn, err := AddressTransport{}.Dial(path)
+	if err != nil {
+		t.Fatalf("Dial() = %v, want nil", err)
+	}
+	conn.Close()
+}
+
+func TestIsLocal(t *testing.T) {
+	for _, network := range []string{"unix", "tcp"} {
+		address := "127.0.0.1:0"
+		if network == "unix" {
+			address = filepath.Join(t.TempDir(), "p.sock")
+		}
+
+		listener, err := net.Listen(network, address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+
+		conn, err := net.Dial(network, listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		accepted, err := listener.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer accepted.Close()
+
+		if got, want := IsLocal(accepted), network == "unix"; got != want {
+			t.Errorf("IsLocal() over %s = %t, want %t", network, got, want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period values are part of the socket protocol as Status.PeriodCode, new
+// periods must only ever be appended.
+type Period int
+
+const (
+	Unknown Period = iota
+	Work
+	Rest
+	Stopped
+	Waiting
+	Prepare
+	LongRest
+)
+
+// PausedPeriodName is reported in Status.Period instead of the period name
+// while the timer is paused.
+const PausedPeriodName = "Paused"
+
+type Status struct {
+	Period         string        `json:"period"`
+	PeriodCode     Period        `json:"period_code"`
+	RestOfTime     time.Duration `json:"rest_of_time"`
+	RestOfTimeStr  string        `json:"rest_of_time_str"`
+	PeriodDuration time.Duration `json:"period_duration"`
+	Paused         bool          `json:"paused"`
+	PausedPeriod   string        `json:"paused_period,omitempty"`
+	NextPeriod     string        `json:"next_period,omitempty"`
+	Overtime       time.Duration `json:"overtime,omitempty"`
+	BreakType      string        `json:"break_type,omitempty"`
+	Snoozes        int           `json:"snoozes,omitempty"`
+	TransitionSeq  uint64        `json:"transition_seq"`
+
+	ProgressPercent        int `json:"progress_percent"`
+	CompletedSessions      int `json:"completed_sessions"`
+	CompletedToday         int `json:"completed_today"`
+	Goal                   int `json:"goal,omitempty"`
+	SessionsUntilLongBreak int `json:"sessions_until_long_break,omitempty"`
+
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+	Task       string     `json:"task,omitempty"`
+	Tag        string     `json:"tag,omitempty"`
+	Timer      string     `json:"timer,omitempty"`
+}
+
+type Request struct {
+	Cmd     string            `json:"command"`
+	Version int               `json:"version,omitempty"`
+	Args    map[string]string `json:"args,omitempty"`
+	Token   string            `json:"token,omitempty"`
+
+	// Timer names the timer the command is for, the main timer when empty.
+	Timer string `json:"timer,omitempty"`
+}
+
+// UnmarshalJSON also accepts the command under the older "cmd" key.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	type request Request
+
+	var envelope struct {
+		request
+		LegacyCmd string `json:"cmd"`
+	}
+
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+
+	*r = Request(envelope.request)
+	if r.Cmd == "" {
+		r.Cmd = envelope.LegacyCmd
+	}
+
+	return nil
+}
+
+func ParseRequest(data []byte) (Request, error) {
+	trimmed := strings.TrimSpace(string(data))
+
+	if !strings.HasPrefix(trimmed, "{") {
+		return Request{Cmd: trimmed}, nil
+	}
+
+	var request Request
+	if err := json.Unmarshal([]byte(trimmed), &request); err != nil {
+		return Request{}, fmt.Errorf("malformed request: %w", err)
+	}
+
+	if request.Version > Version {
+		return Request{}, fmt.Errorf("unsupported protocol version %d, the daemon supports up to %d", request.Version, Version)
+	}
+
+	return request, nil
+}
+
+type Config struct {
+	WorkDuration      time.Duration `json:"work_duration"`
+	RestDuration      time.Duration `json:"rest_duration"`
+	LongRestDuration  time.Duration `json:"long_rest_duration"`
+	LongBreakInterval int           `json:"long_break_interval"`
+	Tick              time.Duration `json:"tick"`
+	Goal              int           `json:"goal,omitempty"`
+
+	// Notifier is the notification backend, NotifyCmds the commands the
+	// exec backend tries and NotifyCommand the template of the command
+	// backend.
+	Notifier      string   `json:"notifier,omitempty"`
+	NotifyCmds    []string `json:"notify_cmds,omitempty"`
+	NotifyCommand string   `json:"notify_command,omitempty"`
+}
+
+type Response struct {
+	Version int            `json:"version"`
+	Status  *Status        `json:"status,omitempty"`
+	Config  *Config        `json:"config,omitempty"`
+	Uptime  *Uptime        `json:"uptime,omitempty"`
+	History []HistoryEntry `json:"history,omitempty"`
+	Days    []DaySummary   `json:"days,omitempty"`
+	Timers  []Status       `json:"timers,omitempty"`
+	Message string         `json:"message,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func (period Period) String() string {
+	switch period {
+	case Work:
+		return "Work"
+	case Rest:
+		return "Rest"
+	case Stopped:
+		return "Stopped"
+	case Waiting:
+		return "Waiting"
+	case Prepare:
+		return "Prepare"
+	case LongRest:
+		return "LongRest"
+	default:
+		return "Unknown"
+	}
+}
+
+func ParsePeriod(name string) (Period, error) {
+	switch strings.ToLower(name) {
+	case "work":
+		return Work, nil
+	case "rest":
+		return Rest, nil
+	case "long-rest", "longrest":
+		return LongRest, nil
+	case "stopped":
+		return Stopped, nil
+	default:
+		return Unknown, fmt.Errorf("unknown period %q", name)
+	}
+}
+
+// String is the default one-line format of get and the status file.
+func (status *Status) String() string {
+	if status.Tag != "" {
+		return fmt.Sprintf("%s %s %s", status.Emoji(), status.RestOfTimeStr, status.Tag)
+	}
+
+	return fmt.Sprintf("%s %s", status.Emoji(), status.RestOfTimeStr)
+}
+
+// GoalProgress is the number of work periods completed today, out of the
+// daily goal when there is one, e.g. 3/8.
+func (status *Status) GoalProgress() string {
+	if status.Goal > 0 {
+		return fmt.Sprintf("%d/%d", status.CompletedToday, status.Goal)
+	}
+
+	return strconv.Itoa(status.CompletedToday)
+}
+
+func (status *Status) Emoji() string {
+	if status.Paused {
+		return "⏯️"
+	}
+
+	return status.PeriodCode.Emoji()
+}
+
+func (period Period) Emoji() string {
+	var emoji string
+
+	switch period {
+	case Work:
+		emoji = "🍅"
+	case Rest:
+		emoji = "😋"
+	case Stopped:
+		emoji = "⏸️"
+	case Waiting:
+		emoji = "⏳"
+	case Prepare:
+		emoji = "🎯"
+	case LongRest:
+		emoji = "🌴"
+	default:
+		emoji = "❓"
+	}
+
+	return emoji
+}
+
+func FormatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	hours := seconds / 3600
+	seconds %= 3600
+	minutes := seconds / 60
+	seconds %= 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
+
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+func FormatShortDuration(d time.Duration) string {
+	s := d.String()
+
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
+type DaySummary struct {
+	Date      string `json:"date"`
+	Completed int    `json:"completed"`
+}
+
+type HistoryEntry struct {
+	Period    string        `json:"period"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   time.Time     `json:"ended_at"`
+	Duration  time.Duration `json:"duration"`
+	Skipped   bool          `json:"skipped"`
+
+	// Suspended is how long the machine slept during the period.
+	Suspended time.Duration `json:"suspended,omitempty"`
+
+	// Idle is how long the user was away while the work period ran, it
+	// is part of Duration but not focus time.
+	Idle time.Duration `json:"idle,omitempty"`
+
+	// Task is the UUID of the Taskwarrior task the work period was
+	// attached to.
+	Task string `json:"task,omitempty"`
+
+	// Tag labels the session the period belonged to.
+	Tag string `json:"tag,omitempty"`
+
+	// Overtime is how long the work period was overrun before the break
+	// was started.
+	Overtime time.Duration `json:"overtime,omitempty"`
+}
+
+type Uptime struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+const (
+	GranularitySecond     = "second"
+	GranularityMinute     = "minute"
+	GranularityTransition = "transition"
+)
@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Request
+	}{
+		{"plain", "get\n", Request{Cmd: "get"}},
+		{"json", `{"command":"toggle","version":1}`, Request{Cmd: "toggle", Version: 1}},
+		{"legacy cmd key", `{"cmd":"switch"}`, Request{Cmd: "switch"}},
+		{"timer", `{"command":"get","timer":"reading"}`, Request{Cmd: "get", Timer: "reading"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRequest([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseRequest(%q) = %v", tt.data, err)
+			}
+
+			if got.Cmd != tt.want.Cmd || got.Version != tt.want.Version || got.Timer != tt.want.Timer {
+				t.Errorf("ParseRequest(%q) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequestErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"command":`,
+		`{"command":42}`,
+		`{"command":"get","version":99}`,
+	} {
+		if _, err := ParseRequest([]byte(data)); err == nil {
+			t.Errorf("ParseRequest(%q) succeeded, want an error", data)
+		}
+	}
+}